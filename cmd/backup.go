@@ -0,0 +1,199 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/uniwise/parrot/internal/backup"
+)
+
+var restoreFlags struct {
+	project   int
+	to        int
+	from      string
+	languages string
+	sync      bool
+}
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Back up every project in poeditor",
+	Long: `Back up every language of every project of the configured api tokens
+into a new timestamped directory in backup.dir, and prune the backups which
+are not kept by backup.keep and backup.maxAge. The server makes the same
+backups in the background when backup.interval is set.
+
+A project which fails to be backed up does not stop the others, but the
+backup is marked incomplete, and the command exits non-zero.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		backuper, err := instantiateBackuper()
+		if err != nil {
+			return err
+		}
+
+		b, err := backuper.Run(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tLANGUAGES")
+
+		for _, m := range b.Projects {
+			fmt.Fprintf(w, "%d\t%d\n", m.ProjectID, len(m.Languages))
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Backed up %d projects to %s\n", len(b.Projects), b.Dir)
+
+		for _, dir := range b.Pruned {
+			fmt.Fprintf(cmd.OutOrStdout(), "Pruned %s\n", dir)
+		}
+
+		if b.Incomplete {
+			fmt.Fprintln(cmd.ErrOrStderr(), backup.Failures(b))
+
+			return errors.Errorf("Backup %s is incomplete, %d accounts and %d projects failed", b.Dir, len(b.FailedAccounts), len(b.Failed))
+		}
+
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a project in poeditor from a backup",
+	Long: `Restore a project in poeditor from a backup made by "parrot backup",
+by uploading every language of the backup. Terms missing from the project are
+added, and translations are overwritten by those of the backup. The languages
+must exist in the project. Poeditor allows an upload every 30 seconds, so
+restoring many languages takes a while.
+
+The latest backup in backup.dir of the project is restored, unless --from is
+given.`,
+	Example: "parrot restore --project 123 --languages da,en",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from := restoreFlags.from
+		if from == "" {
+			backups, err := backup.List(viper.GetString(confBackupDir))
+			if err != nil {
+				return err
+			}
+
+			// An incomplete backup may be missing the project, so the latest backup having it is restored
+			for _, b := range backups {
+				if _, err := os.Stat(backup.ProjectDir(b.Dir, restoreFlags.project)); err == nil {
+					from = b.Dir
+
+					break
+				}
+			}
+
+			if from == "" {
+				return errors.Errorf("No backups of project %d in '%s'", restoreFlags.project, viper.GetString(confBackupDir))
+			}
+		}
+
+		to := restoreFlags.to
+		if to == 0 {
+			to = restoreFlags.project
+		}
+
+		httpClient, err := instantiateHTTPClient()
+		if err != nil {
+			return err
+		}
+
+		clients, err := instantiateClientResolver(httpClient)
+		if err != nil {
+			return err
+		}
+
+		client, err := clients.Client(to)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Restoring project %d from %s to project %d\n", restoreFlags.project, from, to)
+
+		results, err := backup.Restore(cmd.Context(), client, backup.ProjectDir(from, restoreFlags.project), to, backup.RestoreOptions{
+			Languages: languageList(restoreFlags.languages),
+			Sync:      restoreFlags.sync,
+		})
+		if err != nil && len(results) == 0 {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "LANGUAGE\tTERMS ADDED\tTERMS DELETED\tTRANSLATIONS ADDED\tTRANSLATIONS UPDATED")
+
+		for _, r := range results {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\n", r.LanguageCode, r.TermsAdded, r.TermsDeleted, r.TranslationsAdded, r.TranslationsUpdated)
+		}
+
+		if ferr := w.Flush(); err == nil {
+			err = ferr
+		}
+
+		return err
+	},
+}
+
+// nolint:gochecknoinits
+func init() {
+	restoreCmd.Flags().IntVar(&restoreFlags.project, "project", 0, "id of the project in the backup")
+	restoreCmd.Flags().IntVar(&restoreFlags.to, "to", 0, "id of the project in poeditor to restore into (default --project)")
+	restoreCmd.Flags().StringVar(&restoreFlags.from, "from", "", "backup directory to restore from (default the latest backup in backup.dir)")
+	restoreCmd.Flags().StringVar(&restoreFlags.languages, "languages", "all", `comma separated language codes, or "all"`)
+	restoreCmd.Flags().BoolVar(&restoreFlags.sync, "sync", false, "delete the terms of the project which are not in the backup")
+
+	_ = restoreCmd.MarkFlagRequired("project")
+
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+}
+
+// instantiateBackuper returns the backuper of the projects of the configured api tokens.
+func instantiateBackuper() (*backup.Backuper, error) {
+	dir := viper.GetString(confBackupDir)
+	if dir == "" {
+		return nil, errors.Errorf("Backups require %s", confBackupDir)
+	}
+
+	httpClient, err := instantiateHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	clients, err := instantiateClientResolver(httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return backup.NewBackuper(clients, instantiateDownloader(httpClient), dir, backup.Retention{
+		Keep:   viper.GetInt(confBackupKeep),
+		MaxAge: viper.GetDuration(confBackupMaxAge),
+	}), nil
+}
@@ -0,0 +1,398 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/uniwise/parrot/internal/cache"
+	"github.com/uniwise/parrot/internal/project"
+)
+
+var cacheFlags struct {
+	project   int
+	language  string
+	languages string
+	format    string
+	formats   string
+	out       string
+	in        string
+	renew     bool
+}
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Administrate the translation cache",
+	Long: `Administrate the translation cache configured for the server.
+The commands operate on the cache backend directly,
+so they work whether or not the server is running.`,
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached translations, with their age and size",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := instantiateCache(instantiateLogger().WithField("subsystem", "cache"))
+		if err != nil {
+			return err
+		}
+
+		entries, err := cachedEntries(cmd, c)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tLANGUAGE\tFORMAT\tAGE\tSIZE\tEXPIRED\tCHECKSUM")
+
+		for _, e := range entries {
+			age := time.Since(e.CreatedAt)
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%t\t%s\n", e.ProjectID, e.LanguageCode, e.Format, age.Round(time.Second), e.Size, age > c.GetTTL(), e.Checksum)
+		}
+
+		return w.Flush()
+	},
+}
+
+var cacheInspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "Print the metadata of a cached translation",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := instantiateCache(instantiateLogger().WithField("subsystem", "cache"))
+		if err != nil {
+			return err
+		}
+
+		entries, err := cachedEntries(cmd, c)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			if e.LanguageCode != cacheFlags.language || e.Format != cacheFlags.format {
+				continue
+			}
+
+			expires := e.CreatedAt.Add(c.GetTTL())
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "Project:\t%d\n", e.ProjectID)
+			fmt.Fprintf(w, "Language:\t%s\n", e.LanguageCode)
+			fmt.Fprintf(w, "Format:\t%s\n", e.Format)
+			fmt.Fprintf(w, "Cached:\t%s (%s ago)\n", e.CreatedAt.Format(time.RFC3339), time.Since(e.CreatedAt).Round(time.Second))
+			fmt.Fprintf(w, "Expires:\t%s (expired: %t)\n", expires.Format(time.RFC3339), time.Now().After(expires))
+			fmt.Fprintf(w, "Updated in poeditor:\t%s\n", formatOptionalTime(e.Updated))
+			fmt.Fprintf(w, "Checksum:\t%s\n", e.Checksum)
+			fmt.Fprintf(w, "Size:\t%d bytes\n", e.Size)
+
+			return w.Flush()
+		}
+
+		return errors.Errorf("Language %s format %s of project %d is not cached", cacheFlags.language, cacheFlags.format, cacheFlags.project)
+	},
+}
+
+var cachePurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Purge the cached translations of a project, or of a language of it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := instantiateCache(instantiateLogger().WithField("subsystem", "cache"))
+		if err != nil {
+			return err
+		}
+
+		if cacheFlags.language != "" {
+			if err := c.PurgeTranslation(cmd.Context(), cacheFlags.project, cacheFlags.language); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Purged language %s of project %d\n", cacheFlags.language, cacheFlags.project)
+
+			return nil
+		}
+
+		if err := c.PurgeProject(cmd.Context(), cacheFlags.project); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Purged project %d\n", cacheFlags.project)
+
+		return nil
+	},
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Fetch translations into the cache",
+	Long: `Fetch translations from poeditor into the cache, unless they are
+cached and unchanged in poeditor. Without --project, the translations
+configured in cache.warmup are warmed.`,
+	Example: "parrot cache warm --project 123 --languages da,en --formats json,key_value_json",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		logger := instantiateLogger()
+
+		c, err := instantiateCache(logger.WithField("subsystem", "cache"))
+		if err != nil {
+			return err
+		}
+
+		httpClient, err := instantiateHTTPClient()
+		if err != nil {
+			return err
+		}
+
+		svc, err := instantiateService(logger, c, httpClient)
+		if err != nil {
+			return err
+		}
+
+		targets, err := warmupTargets()
+		if err != nil {
+			return err
+		}
+
+		if cacheFlags.project != 0 {
+			targets = []project.WarmupTarget{{
+				Project:   cacheFlags.project,
+				Languages: languageList(cacheFlags.languages),
+				Formats:   formatList(cacheFlags.formats),
+			}}
+
+			if err := checkWarmupTargets(targets); err != nil {
+				return errors.Wrap(err, "Invalid --formats")
+			}
+		}
+
+		if len(targets) == 0 {
+			return errors.Errorf("Nothing to warm, use --project or configure %s", confCacheWarmup)
+		}
+
+		failed := 0
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tLANGUAGE\tFORMAT\tRESULT")
+
+		for _, r := range svc.Warm(cmd.Context(), targets) {
+			result := "cached"
+
+			switch {
+			case r.Err != nil:
+				result = r.Err.Error()
+				failed++
+			case r.Fetched:
+				result = "fetched"
+			}
+
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.ProjectID, r.LanguageCode, r.Format, result)
+		}
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if failed > 0 {
+			return errors.Errorf("Failed to warm %d translations", failed)
+		}
+
+		return nil
+	},
+}
+
+var cacheDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Dump every cached translation to an archive",
+	Long: `Dump every cached translation, with its checksum and age, to a gzip
+compressed tar archive. The archive can be restored into any cache backend
+with "parrot cache restore", such as when migrating between backends.`,
+	Example: "parrot cache dump --out cache.tar.gz",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := instantiateCache(instantiateLogger().WithField("subsystem", "cache"))
+		if err != nil {
+			return err
+		}
+
+		dumped, err := dumpCache(cmd.Context(), c, cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+
+		// The report goes to stderr, as the archive may be written to stdout
+		fmt.Fprintf(cmd.ErrOrStderr(), "Dumped %d translations\n", dumped)
+
+		return nil
+	},
+}
+
+var cacheRestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore cached translations from an archive",
+	Long: `Restore the translations of an archive written by "parrot cache dump"
+into the cache. Translations keep their age, so translations older than
+cache.ttl are skipped, unless --renew restores them as if cached now.`,
+	Example: "parrot cache restore --in cache.tar.gz",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := instantiateCache(instantiateLogger().WithField("subsystem", "cache"))
+		if err != nil {
+			return err
+		}
+
+		var r io.Reader = cmd.InOrStdin()
+
+		if cacheFlags.in != "" {
+			f, err := os.Open(cacheFlags.in)
+			if err != nil {
+				return errors.Wrapf(err, "Failed to open %s", cacheFlags.in)
+			}
+			defer f.Close()
+
+			r = f
+		}
+
+		result, err := cache.Restore(cmd.Context(), c, r, cache.RestoreOptions{Renew: cacheFlags.renew})
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Restored %d translations\n", result.Restored)
+
+		if result.Expired > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "Skipped %d translations older than the cache ttl, use --renew to restore them\n", result.Expired)
+		}
+
+		return nil
+	},
+}
+
+// nolint:gochecknoinits
+func init() {
+	cacheListCmd.Flags().IntVar(&cacheFlags.project, "project", 0, "only list translations of the project")
+
+	cacheInspectCmd.Flags().IntVar(&cacheFlags.project, "project", 0, "id of the project in poeditor")
+	cacheInspectCmd.Flags().StringVar(&cacheFlags.language, "language", "", "language code")
+	cacheInspectCmd.Flags().StringVar(&cacheFlags.format, "format", "key_value_json", "format of the translation")
+	_ = cacheInspectCmd.MarkFlagRequired("project")
+	_ = cacheInspectCmd.MarkFlagRequired("language")
+
+	cachePurgeCmd.Flags().IntVar(&cacheFlags.project, "project", 0, "id of the project in poeditor")
+	cachePurgeCmd.Flags().StringVar(&cacheFlags.language, "language", "", "only purge the language code")
+	_ = cachePurgeCmd.MarkFlagRequired("project")
+
+	cacheWarmCmd.Flags().IntVar(&cacheFlags.project, "project", 0, "id of the project in poeditor")
+	cacheWarmCmd.Flags().StringVar(&cacheFlags.languages, "languages", "all", `comma separated language codes, or "all"`)
+	cacheWarmCmd.Flags().StringVar(&cacheFlags.formats, "formats", "key_value_json", "comma separated formats")
+
+	cacheDumpCmd.Flags().StringVar(&cacheFlags.out, "out", "", "file to write the archive to (default stdout)")
+
+	cacheRestoreCmd.Flags().StringVar(&cacheFlags.in, "in", "", "file to read the archive from (default stdin)")
+	cacheRestoreCmd.Flags().BoolVar(&cacheFlags.renew, "renew", false, "restore translations as if cached now, instead of keeping their age")
+
+	cacheCmd.AddCommand(cacheListCmd, cacheInspectCmd, cachePurgeCmd, cacheWarmCmd, cacheDumpCmd, cacheRestoreCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// cachedEntries returns the cached translations, of the project flag if set, sorted by project, language and format.
+func cachedEntries(cmd *cobra.Command, c cache.Cache) ([]cache.CacheEntry, error) {
+	all, err := c.ListTranslations(cmd.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []cache.CacheEntry{}
+	for _, e := range all {
+		if cacheFlags.project == 0 || e.ProjectID == cacheFlags.project {
+			entries = append(entries, e)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ProjectID != b.ProjectID {
+			return a.ProjectID < b.ProjectID
+		}
+
+		if a.LanguageCode != b.LanguageCode {
+			return a.LanguageCode < b.LanguageCode
+		}
+
+		return a.Format < b.Format
+	})
+
+	return entries, nil
+}
+
+// formatList parses a comma separated list of formats, skipping empty entries.
+func formatList(s string) []string {
+	var formats []string
+
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			formats = append(formats, f)
+		}
+	}
+
+	return formats
+}
+
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func dumpCache(ctx context.Context, c cache.Cache, stdout io.Writer) (int, error) {
+	if cacheFlags.out == "" {
+		return cache.Dump(ctx, c, stdout)
+	}
+
+	// Dump into a temporary file, which is moved into place once complete, such that a failed dump leaves no partial archive
+	tmp, err := os.CreateTemp(filepath.Dir(cacheFlags.out), ".parrot-*")
+	if err != nil {
+		return 0, errors.Wrapf(err, "Failed to create %s", cacheFlags.out)
+	}
+	defer os.Remove(tmp.Name())
+
+	dumped, err := cache.Dump(ctx, c, tmp)
+	if cerr := tmp.Close(); err == nil && cerr != nil {
+		err = errors.Wrapf(cerr, "Failed to write %s", cacheFlags.out)
+	}
+	if err != nil {
+		return dumped, err
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return dumped, errors.Wrapf(err, "Failed to set permissions of %s", cacheFlags.out)
+	}
+
+	if err := os.Rename(tmp.Name(), cacheFlags.out); err != nil {
+		return dumped, errors.Wrapf(err, "Failed to move %s into place", cacheFlags.out)
+	}
+
+	return dumped, nil
+}
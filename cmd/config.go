@@ -0,0 +1,604 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cast"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/uniwise/parrot/internal/rest"
+	"gopkg.in/yaml.v2"
+)
+
+const redacted = "<redacted>"
+
+type configKind int
+
+const (
+	kindString configKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindDuration
+	kindStringSlice
+	kindMap
+	kindList
+)
+
+// configKey is a key of the configuration, with the type of its value.
+// Values of keys with a redact function are passed through it before being shown.
+type configKey struct {
+	key    string
+	kind   configKind
+	redact func(v interface{}) interface{}
+}
+
+// configKeys are the keys read by the commands, in the order of the README.
+var configKeys = []configKey{
+	{key: confServerPort, kind: kindInt},
+	{key: confServerGrace, kind: kindDuration},
+	{key: confServerTrustedProxies, kind: kindStringSlice},
+	{key: confConfigWatch, kind: kindBool},
+	{key: confLogLevel, kind: kindString},
+	{key: confLogFormat, kind: kindString},
+	{key: confCacheType, kind: kindString},
+	{key: confCacheTTL, kind: kindDuration},
+	{key: confCacheRenewalThreshold, kind: kindDuration},
+	{key: confCacheProjectTTL, kind: kindDuration},
+	{key: confCacheWarmup, kind: kindList},
+	{key: confCacheFSDir, kind: kindString},
+	{key: confCacheRedisMode, kind: kindString},
+	{key: confCacheRedisAddress, kind: kindString},
+	{key: confCacheRedisUser, kind: kindString},
+	{key: confCacheRedisPassword, kind: kindString, redact: redactSecret},
+	{key: confCacheRedisMaxRetries, kind: kindInt},
+	{key: confCacheRedisDB, kind: kindInt},
+	{key: confCacheRedisSentinelMaster, kind: kindString},
+	{key: confCacheRedisSentinelAddress, kind: kindStringSlice},
+	{key: confCacheRedisSentinelPassword, kind: kindString, redact: redactSecret},
+	{key: confSnapshotPath, kind: kindString},
+	{key: confSnapshotOffline, kind: kindBool},
+	{key: confSnapshotSeed, kind: kindBool},
+	{key: confBackupDir, kind: kindString},
+	{key: confBackupInterval, kind: kindDuration},
+	{key: confBackupKeep, kind: kindInt},
+	{key: confBackupMaxAge, kind: kindDuration},
+	{key: confRateLimitEnabled, kind: kindBool},
+	{key: confRateLimitHitsRate, kind: kindFloat},
+	{key: confRateLimitHitsBurst, kind: kindInt},
+	{key: confRateLimitMissesRate, kind: kindFloat},
+	{key: confRateLimitMissesBurst, kind: kindInt},
+	{key: confPrometheusEnabled, kind: kindBool},
+	{key: confPrometheusPath, kind: kindString},
+	{key: confPrometheusPort, kind: kindInt},
+	{key: confAPIToken, kind: kindString, redact: redactToken},
+	{key: confAPITokens, kind: kindMap, redact: redactTokens},
+	{key: confAPIMaxConcurrentFetches, kind: kindInt},
+	{key: confAPIMaxQueuedFetches, kind: kindInt},
+	{key: confAPIRetries, kind: kindInt},
+	{key: confAPIRetryWait, kind: kindDuration},
+	{key: confAPIRetryMaxWait, kind: kindDuration},
+	{key: confAPIBreakerThreshold, kind: kindInt},
+	{key: confAPIBreakerCooldown, kind: kindDuration},
+	{key: confOutboundConnectTimeout, kind: kindDuration},
+	{key: confOutboundReadTimeout, kind: kindDuration},
+	{key: confOutboundTimeout, kind: kindDuration},
+	{key: confOutboundProxy, kind: kindString, redact: redactURL},
+	{key: confOutboundCAFile, kind: kindString},
+	{key: confOutboundMaxResponseSize, kind: kindInt},
+	{key: confOutboundAllowedSchemes, kind: kindStringSlice},
+	{key: confOutboundAllowedHosts, kind: kindStringSlice},
+	{key: confAuthEnabled, kind: kindBool},
+	{key: confAuthKeys, kind: kindList, redact: redactAPIKeys},
+	{key: confAuthJWTJWKS, kind: kindString},
+	{key: confAuthJWTRefreshInterval, kind: kindDuration},
+	{key: confAuthJWTIssuer, kind: kindString},
+	{key: confAuthJWTAudience, kind: kindString},
+	{key: confAuthJWTProjectsClaim, kind: kindString},
+}
+
+var configFlags struct {
+	offline bool
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Validate and show the configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration",
+	Long: `Validate the configuration, from the config file and the environment.
+The types of all values and the required combinations of keys are checked,
+and unless --offline is given, the cache backend is pinged, the poeditor api
+tokens are verified by listing their projects, and the jwks is loaded.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		problems := validateConfig(cmd.Context(), !configFlags.offline)
+
+		errs := 0
+		for _, p := range problems {
+			severity := "warning"
+			if !p.warning {
+				severity = "error"
+				errs++
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s: %s\n", severity, p.key, p.err)
+		}
+
+		if errs > 0 {
+			return errors.Errorf("Configuration is invalid, found %d errors", errs)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Configuration is valid, with %d warnings\n", len(problems))
+
+		return nil
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the effective configuration, with secrets redacted",
+	Long: `Print the effective configuration as yaml, combining defaults,
+the config file and the environment. Passwords and tokens are redacted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		settings := map[string]interface{}{}
+
+		for _, k := range configKeys {
+			v := effectiveValue(k)
+			if v == nil {
+				continue
+			}
+
+			setNested(settings, strings.Split(k.key, "."), v)
+		}
+
+		b, err := yaml.Marshal(settings)
+		if err != nil {
+			return errors.Wrap(err, "Failed to print configuration")
+		}
+
+		_, err = cmd.OutOrStdout().Write(b)
+
+		return err
+	},
+}
+
+// nolint:gochecknoinits
+func init() {
+	configValidateCmd.Flags().BoolVar(&configFlags.offline, "offline", false, "skip the checks connecting to the cache, poeditor and the jwks")
+
+	configCmd.AddCommand(configValidateCmd, configShowCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// effectiveValue returns the value of a key as it is read by the commands, redacted if the key is secret.
+// Values which can not be read as the type of the key are returned as is.
+func effectiveValue(k configKey) interface{} {
+	v := viper.Get(k.key)
+	if v == nil {
+		return nil
+	}
+
+	if parsed, err := parseValue(k.kind, v); err == nil {
+		v = parsed
+	}
+
+	if k.redact != nil {
+		v = k.redact(v)
+	}
+
+	return v
+}
+
+func parseValue(kind configKind, v interface{}) (interface{}, error) {
+	switch kind {
+	case kindString:
+		return cast.ToStringE(v)
+	case kindInt:
+		return cast.ToIntE(v)
+	case kindFloat:
+		return cast.ToFloat64E(v)
+	case kindBool:
+		return cast.ToBoolE(v)
+	case kindDuration:
+		d, err := cast.ToDurationE(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return d.String(), nil
+	case kindStringSlice:
+		return cast.ToStringSliceE(v)
+	case kindMap:
+		return cast.ToStringMapStringE(v)
+	default:
+		return v, nil
+	}
+}
+
+func setNested(m map[string]interface{}, path []string, v interface{}) {
+	if len(path) == 1 {
+		m[path[0]] = v
+
+		return
+	}
+
+	child, ok := m[path[0]].(map[string]interface{})
+	if !ok {
+		child = map[string]interface{}{}
+		m[path[0]] = child
+	}
+
+	setNested(child, path[1:], v)
+}
+
+func redactSecret(v interface{}) interface{} {
+	if s, ok := v.(string); ok && s == "" {
+		return s
+	}
+
+	return redacted
+}
+
+// redactToken redacts an api token, unless it refers to a file holding the token.
+func redactToken(v interface{}) interface{} {
+	if s, ok := v.(string); ok && strings.HasPrefix(s, "file:") {
+		return s
+	}
+
+	return redactSecret(v)
+}
+
+func redactTokens(v interface{}) interface{} {
+	tokens, ok := v.(map[string]string)
+	if !ok {
+		return redacted
+	}
+
+	result := make(map[string]interface{}, len(tokens))
+	for key, token := range tokens {
+		result[key] = redactToken(token)
+	}
+
+	return result
+}
+
+// redactURL redacts the password of a url.
+func redactURL(v interface{}) interface{} {
+	s, _ := v.(string)
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return redacted
+	}
+
+	return u.Redacted()
+}
+
+func redactAPIKeys(v interface{}) interface{} {
+	keys, ok := v.([]interface{})
+	if !ok {
+		return redacted
+	}
+
+	result := make([]interface{}, 0, len(keys))
+	for _, k := range keys {
+		m, err := cast.ToStringMapE(k)
+		if err != nil {
+			result = append(result, redacted)
+
+			continue
+		}
+
+		copied := make(map[string]interface{}, len(m))
+		for field, value := range m {
+			copied[field] = value
+		}
+
+		if _, ok := copied["key"]; ok {
+			copied["key"] = redacted
+		}
+
+		result = append(result, copied)
+	}
+
+	return result
+}
+
+// configProblem is an invalid value of a key, or a warning about it.
+type configProblem struct {
+	key     string
+	err     error
+	warning bool
+}
+
+// validateConfig validates the configuration, returning the problems found. If online is set,
+// the cache backend, the poeditor api tokens and the jwks are checked by connecting to them.
+func validateConfig(ctx context.Context, online bool) []configProblem {
+	var problems []configProblem
+
+	fail := func(key string, err error) {
+		problems = append(problems, configProblem{key: key, err: err})
+	}
+
+	warn := func(key string, err error) {
+		problems = append(problems, configProblem{key: key, err: err, warning: true})
+	}
+
+	for _, k := range configKeys {
+		if v := viper.Get(k.key); v != nil {
+			if _, err := parseValue(k.kind, v); err != nil {
+				fail(k.key, errors.Errorf("Invalid value '%v'", v))
+			}
+		}
+	}
+
+	// The remaining checks read values as their types, which are only meaningful if every value has a valid type
+	if len(problems) > 0 {
+		return problems
+	}
+
+	if _, err := logrus.ParseLevel(viper.GetString(confLogLevel)); err != nil {
+		fail(confLogLevel, err)
+	}
+
+	if f := viper.GetString(confLogFormat); f != "json" && f != "text" {
+		fail(confLogFormat, errors.Errorf("Unknown log format '%s', use json or text", f))
+	}
+
+	for _, key := range []string{confServerPort, confPrometheusPort} {
+		if port := viper.GetInt(key); port < 1 || port > 65535 {
+			fail(key, errors.Errorf("Invalid port %d", port))
+		}
+	}
+
+	if _, err := rest.IPExtractor(viper.GetStringSlice(confServerTrustedProxies)); err != nil {
+		fail(confServerTrustedProxies, err)
+	}
+
+	if viper.GetBool(confPrometheusEnabled) && viper.GetInt(confPrometheusPort) == viper.GetInt(confServerPort) {
+		fail(confPrometheusPort, errors.New("Prometheus can not share the port of the server"))
+	}
+
+	validateSnapshotConfig(fail)
+	validateBackupConfig(fail, warn)
+
+	// Offline, translations are served from the snapshot, without the cache and poeditor
+	if !viper.GetBool(confSnapshotOffline) {
+		validateCacheConfig(fail, warn)
+		validateAPIConfig(fail, warn)
+	}
+
+	validateAuthConfig(fail)
+
+	if viper.GetBool(confRateLimitEnabled) {
+		for _, key := range []string{confRateLimitHitsRate, confRateLimitMissesRate} {
+			if viper.GetFloat64(key) <= 0 {
+				fail(key, errors.New("Rate must be positive"))
+			}
+		}
+	}
+
+	if _, err := instantiateHTTPClient(); err != nil {
+		fail("outbound", err)
+	}
+
+	if online && len(problems) == 0 {
+		problems = append(problems, checkConnections(ctx)...)
+	}
+
+	return problems
+}
+
+func validateCacheConfig(fail, warn func(key string, err error)) {
+	if viper.GetDuration(confCacheTTL) <= 0 {
+		fail(confCacheTTL, errors.New("TTL must be positive"))
+	}
+
+	if viper.GetDuration(confCacheRenewalThreshold) >= viper.GetDuration(confCacheTTL) {
+		warn(confCacheRenewalThreshold, errors.New("Threshold is not below the ttl, so cached translations are renewed on every request"))
+	}
+
+	switch cType := viper.GetString(confCacheType); cType {
+	case "filesystem":
+		if viper.GetString(confCacheFSDir) == "" {
+			fail(confCacheFSDir, errors.New("Required by the filesystem cache"))
+		}
+	case "redis":
+		switch mode := viper.GetString(confCacheRedisMode); mode {
+		case "single":
+			if viper.GetString(confCacheRedisAddress) == "" {
+				fail(confCacheRedisAddress, errors.New("Required in single redis mode"))
+			}
+		case "sentinel":
+			if viper.GetString(confCacheRedisSentinelMaster) == "" {
+				fail(confCacheRedisSentinelMaster, errors.New("Required in sentinel redis mode"))
+			}
+
+			if len(viper.GetStringSlice(confCacheRedisSentinelAddress)) == 0 {
+				fail(confCacheRedisSentinelAddress, errors.New("Required in sentinel redis mode"))
+			}
+		default:
+			fail(confCacheRedisMode, errors.Errorf("Unsupported redis mode '%s', use single or sentinel", mode))
+		}
+	default:
+		fail(confCacheType, errors.Errorf("Unknown cache type '%s', use filesystem or redis", cType))
+	}
+
+	if _, err := warmupTargets(); err != nil {
+		fail(confCacheWarmup, err)
+	}
+}
+
+func validateSnapshotConfig(fail func(key string, err error)) {
+	snapshotPath := viper.GetString(confSnapshotPath)
+	if snapshotPath == "" {
+		for _, key := range []string{confSnapshotOffline, confSnapshotSeed} {
+			if viper.GetBool(key) {
+				fail(confSnapshotPath, errors.Errorf("Required by %s", key))
+			}
+		}
+
+		return
+	}
+
+	// Only check that the snapshot exists, as loading it on every reload is expensive. It is loaded when serving
+	if _, err := os.Stat(snapshotPath); err != nil {
+		fail(confSnapshotPath, errors.Wrapf(err, "Failed to read snapshot '%s'", snapshotPath))
+	}
+}
+
+func validateBackupConfig(fail, warn func(key string, err error)) {
+	for _, key := range []string{confBackupInterval, confBackupMaxAge} {
+		if viper.GetDuration(key) < 0 {
+			fail(key, errors.New("Duration must not be negative"))
+		}
+	}
+
+	if viper.GetInt(confBackupKeep) < 0 {
+		fail(confBackupKeep, errors.New("Number of backups must not be negative"))
+	}
+
+	if viper.GetDuration(confBackupInterval) <= 0 {
+		return
+	}
+
+	if viper.GetString(confBackupDir) == "" {
+		fail(confBackupDir, errors.Errorf("Required by %s", confBackupInterval))
+	}
+
+	if viper.GetBool(confSnapshotOffline) {
+		warn(confBackupInterval, errors.New("Backups are not made in offline mode"))
+	}
+}
+
+func validateAPIConfig(fail, warn func(key string, err error)) {
+	if viper.GetString(confAPIToken) == "" && len(viper.GetStringMapString(confAPITokens)) == 0 {
+		fail(confAPIToken, errors.Errorf("No poeditor api token configured, set %s or %s", confAPIToken, confAPITokens))
+	}
+
+	if _, err := instantiateClientResolver(nil); err != nil {
+		fail(confAPITokens, err)
+	}
+
+	if viper.GetInt(confAPIMaxConcurrentFetches) < 1 {
+		fail(confAPIMaxConcurrentFetches, errors.New("At least one concurrent fetch is required"))
+	}
+
+	if viper.GetInt(confAPIRetries) < 0 {
+		fail(confAPIRetries, errors.New("Retries can not be negative"))
+	}
+
+	if viper.GetDuration(confAPIRetryWait) > viper.GetDuration(confAPIRetryMaxWait) {
+		warn(confAPIRetryWait, errors.Errorf("Wait is above %s, which caps it", confAPIRetryMaxWait))
+	}
+}
+
+func validateAuthConfig(fail func(key string, err error)) {
+	keys, err := apiKeys()
+	if err != nil {
+		fail(confAuthKeys, err)
+
+		return
+	}
+
+	if _, err := rest.NewAPIKeyAuthenticator(keys); err != nil {
+		fail(confAuthKeys, err)
+	}
+
+	if viper.GetBool(confAuthEnabled) && len(keys) == 0 && viper.GetString(confAuthJWTJWKS) == "" {
+		fail(confAuthEnabled, errors.Errorf("Auth is enabled without %s or %s, which denies every request", confAuthKeys, confAuthJWTJWKS))
+	}
+
+	if viper.GetBool(confAuthEnabled) && viper.GetString(confAuthJWTJWKS) != "" {
+		for _, key := range []string{confAuthJWTIssuer, confAuthJWTAudience} {
+			if viper.GetString(key) == "" {
+				fail(key, errors.Errorf("Required by %s, as every jwt is rejected without it", confAuthJWTJWKS))
+			}
+		}
+	}
+}
+
+// checkConnections checks that the cache backend, poeditor and the jwks can be reached with the configuration.
+func checkConnections(ctx context.Context) []configProblem {
+	var problems []configProblem
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*30)
+	defer cancel()
+
+	if !viper.GetBool(confSnapshotOffline) {
+		problems = append(problems, checkUpstreamConnections(ctx)...)
+	}
+
+	if source := viper.GetString(confAuthJWTJWKS); source != "" {
+		if _, err := rest.NewJWKS(source); err != nil {
+			problems = append(problems, configProblem{key: confAuthJWTJWKS, err: err})
+		}
+	}
+
+	return problems
+}
+
+// checkUpstreamConnections checks that the cache backend and poeditor can be reached with the configuration.
+func checkUpstreamConnections(ctx context.Context) []configProblem {
+	var problems []configProblem
+
+	c, err := instantiateCache(logrus.NewEntry(logrus.StandardLogger()))
+	if err == nil {
+		err = c.PingContext(ctx)
+	}
+
+	if err != nil {
+		problems = append(problems, configProblem{key: confCacheType, err: errors.Wrap(err, "Cache is unreachable")})
+	}
+
+	httpClient, err := instantiateHTTPClient()
+	if err != nil {
+		return append(problems, configProblem{key: "outbound", err: err})
+	}
+
+	clients, err := instantiateClientResolver(httpClient)
+	if err != nil {
+		return append(problems, configProblem{key: confAPITokens, err: err})
+	}
+
+	tokenErrs := clients.Verify(ctx)
+
+	keys := make([]string, 0, len(tokenErrs))
+	for key := range tokenErrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		problems = append(problems, configProblem{key: confAPITokens + "." + key, err: errors.Wrap(tokenErrs[key], "Failed to verify api token")})
+	}
+
+	return problems
+}
@@ -0,0 +1,108 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/uniwise/parrot/internal/snapshot"
+)
+
+var exportFlags struct {
+	project   int
+	languages string
+	format    string
+	out       string
+}
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the translations of a project into a directory",
+	Long: `Export the translations of a project from poeditor into a directory,
+along with a manifest of the exported languages and their checksums.
+The directory can be bundled with an app as offline fallback translations.`,
+	Example: "parrot export --project 123 --languages all --format key_value_json --out ./i18n",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		httpClient, err := instantiateHTTPClient()
+		if err != nil {
+			return err
+		}
+
+		clients, err := instantiateClientResolver(httpClient)
+		if err != nil {
+			return err
+		}
+
+		client, err := clients.Client(exportFlags.project)
+		if err != nil {
+			return err
+		}
+
+		exporter := snapshot.NewExporter(client, instantiateDownloader(httpClient))
+
+		manifest, err := exporter.Export(cmd.Context(), exportFlags.project, languageList(exportFlags.languages), exportFlags.format, exportFlags.out)
+		if err != nil {
+			return err
+		}
+
+		codes := make([]string, 0, len(manifest.Languages))
+		for code := range manifest.Languages {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+
+		for _, code := range codes {
+			l := manifest.Languages[code]
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%d bytes\t%s\n", code, l.File, l.Size, l.Checksum)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Exported %d languages of project %d to %s\n", len(codes), manifest.ProjectID, exportFlags.out)
+
+		return nil
+	},
+}
+
+// nolint:gochecknoinits
+func init() {
+	exportCmd.Flags().IntVar(&exportFlags.project, "project", 0, "id of the project in poeditor")
+	exportCmd.Flags().StringVar(&exportFlags.languages, "languages", "all", `comma separated language codes, or "all"`)
+	exportCmd.Flags().StringVar(&exportFlags.format, "format", "key_value_json", "format of the exported files")
+	exportCmd.Flags().StringVar(&exportFlags.out, "out", ".", "directory to export into")
+
+	_ = exportCmd.MarkFlagRequired("project")
+
+	rootCmd.AddCommand(exportCmd)
+}
+
+// languageList parses a comma separated list of language codes. "all" and an empty list yield nil, meaning every language.
+func languageList(s string) []string {
+	var languages []string
+
+	for _, l := range strings.Split(s, ",") {
+		l = strings.TrimSpace(l)
+		if l == "" || l == "all" {
+			continue
+		}
+
+		languages = append(languages, l)
+	}
+
+	return languages
+}
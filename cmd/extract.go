@@ -0,0 +1,139 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/uniwise/parrot/internal/terms"
+)
+
+var extractFlags struct {
+	patterns   []string
+	extensions []string
+	format     string
+	out        string
+	project    int
+}
+
+// extractCmd represents the extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract [paths...]",
+	Short: "Extract the terms used by source code",
+	Long: `Extract the terms used by source code, by scanning files for translation calls.
+The terms are written with the file:line of every use as reference, in a format
+which can be pushed with "parrot push" or imported into poeditor.
+
+A pattern is a regular expression, whose first group captures the term.
+A group named context, if present, captures the context of the term.
+
+With --project, the terms of the project which are not used by the source
+code are reported, so they can be cleaned up. Terms are matched by term alone,
+unless every pattern captures the context.`,
+	Example: `parrot extract ./cmd ./web --out terms.po
+parrot extract --pattern '\btr\("([^"]+)"' --ext .go --project 123`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+
+		extractor, err := terms.NewExtractor(extractFlags.patterns, extractFlags.extensions)
+		if err != nil {
+			return err
+		}
+
+		entries, err := extractor.Extract(args)
+		if err != nil {
+			return err
+		}
+
+		if err := writeExtracted(cmd.OutOrStdout(), entries); err != nil {
+			return err
+		}
+
+		// The report goes to stderr, as the terms may be written to stdout
+		report := cmd.ErrOrStderr()
+		fmt.Fprintf(report, "Extracted %d terms\n", len(entries))
+
+		if extractFlags.project == 0 {
+			return nil
+		}
+
+		httpClient, err := instantiateHTTPClient()
+		if err != nil {
+			return err
+		}
+
+		clients, err := instantiateClientResolver(httpClient)
+		if err != nil {
+			return err
+		}
+
+		client, err := clients.Client(extractFlags.project)
+		if err != nil {
+			return err
+		}
+
+		current, err := terms.Current(cmd.Context(), client, extractFlags.project, "")
+		if err != nil {
+			return err
+		}
+
+		unused := extractor.Unused(current, entries)
+		for _, e := range unused {
+			fmt.Fprintf(report, "unused: %s\n", termName(e))
+		}
+
+		fmt.Fprintf(report, "%d of %d terms of project %d are unused\n", len(unused), len(current), extractFlags.project)
+
+		return nil
+	},
+}
+
+// nolint:gochecknoinits
+func init() {
+	extractCmd.Flags().StringArrayVar(&extractFlags.patterns, "pattern", terms.DefaultPatterns, "regular expression matching a translation call, can be repeated")
+	extractCmd.Flags().StringSliceVar(&extractFlags.extensions, "ext", terms.DefaultExtensions, "extensions of the files to scan")
+	extractCmd.Flags().StringVar(&extractFlags.format, "format", "po", "format to write the terms in, one of "+strings.Join(terms.WriteFormats, ", "))
+	extractCmd.Flags().StringVar(&extractFlags.out, "out", "", "file to write the terms to (default stdout)")
+	extractCmd.Flags().IntVar(&extractFlags.project, "project", 0, "report the terms of the project in poeditor which are unused")
+
+	rootCmd.AddCommand(extractCmd)
+}
+
+func writeExtracted(stdout io.Writer, entries []terms.Entry) error {
+	if extractFlags.out == "" {
+		return terms.Write(extractFlags.format, stdout, entries)
+	}
+
+	f, err := os.Create(extractFlags.out)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to create %s", extractFlags.out)
+	}
+
+	if err := terms.Write(extractFlags.format, f, entries); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return errors.Wrapf(f.Close(), "Failed to write %s", extractFlags.out)
+}
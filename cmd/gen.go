@@ -0,0 +1,123 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/uniwise/parrot/internal/codegen"
+	"github.com/uniwise/parrot/internal/terms"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+var genFlags struct {
+	project   int
+	pkg       string
+	language  string
+	out       string
+	accessors bool
+}
+
+// genCmd represents the gen command
+var genCmd = &cobra.Command{
+	Use:   "gen",
+	Short: "Generate code for the terms of a project",
+}
+
+var genGoCmd = &cobra.Command{
+	Use:   "go",
+	Short: "Generate go constants and accessors for the terms of a project",
+	Long: `Generate go code with a constant for the key of every term of a project,
+and a typed accessor function for every term, which fetches its translation
+with the client of pkg/client. The placeholders of the translation in the
+reference language, such as {name} and %d, become typed parameters.
+
+A term renamed or removed in poeditor then causes a compile error in the
+services using the generated code, instead of an error at runtime.`,
+	Example: `parrot gen go --project 123 --package i18nkeys --out ./i18nkeys/keys.go`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		httpClient, err := instantiateHTTPClient()
+		if err != nil {
+			return err
+		}
+
+		clients, err := instantiateClientResolver(httpClient)
+		if err != nil {
+			return err
+		}
+
+		client, err := clients.Client(genFlags.project)
+		if err != nil {
+			return err
+		}
+
+		language := genFlags.language
+		if language == "" {
+			res, err := client.ViewProject(cmd.Context(), poedit.ViewProjectRequest{ID: genFlags.project})
+			if err != nil {
+				return errors.Wrapf(err, "Failed to view project %d", genFlags.project)
+			}
+
+			language = res.Result.Project.ReferenceLanguage
+		}
+
+		entries, err := terms.Current(cmd.Context(), client, genFlags.project, language)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+		if err := codegen.GenerateGo(&buf, codegen.GoOptions{
+			Package:   genFlags.pkg,
+			ProjectID: genFlags.project,
+			Accessors: genFlags.accessors,
+		}, entries); err != nil {
+			return err
+		}
+
+		if genFlags.out == "" {
+			_, err := buf.WriteTo(cmd.OutOrStdout())
+
+			return err
+		}
+
+		if err := ioutil.WriteFile(genFlags.out, buf.Bytes(), 0o644); err != nil { // nolint:gosec
+			return errors.Wrapf(err, "Failed to write %s", genFlags.out)
+		}
+
+		fmt.Fprintf(cmd.ErrOrStderr(), "Generated %s for %d terms of project %d\n", genFlags.out, len(entries), genFlags.project)
+
+		return nil
+	},
+}
+
+// nolint:gochecknoinits
+func init() {
+	genGoCmd.Flags().IntVar(&genFlags.project, "project", 0, "id of the project in poeditor")
+	genGoCmd.Flags().StringVar(&genFlags.pkg, "package", "i18nkeys", "name of the generated package")
+	genGoCmd.Flags().StringVar(&genFlags.language, "language", "", "language to read placeholders from (default the reference language of the project)")
+	genGoCmd.Flags().StringVar(&genFlags.out, "out", "", "file to write the code to (default stdout)")
+	genGoCmd.Flags().BoolVar(&genFlags.accessors, "accessors", true, "generate an accessor function for every term, instead of only constants")
+
+	_ = genGoCmd.MarkFlagRequired("project")
+
+	genCmd.AddCommand(genGoCmd)
+	rootCmd.AddCommand(genCmd)
+}
@@ -0,0 +1,199 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/uniwise/parrot/internal/terms"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+var pushFlags struct {
+	project      int
+	file         string
+	format       string
+	language     string
+	dryRun       bool
+	delete       bool
+	sync         bool
+	fuzzyTrigger bool
+}
+
+// pushCmd represents the push command
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Push the terms of a source language file to a project",
+	Long: `Push the terms of a source language file to a project in poeditor.
+The terms of the file are compared with the terms of the project, and the
+added, removed and changed terms are reported before anything is applied.
+
+Terms missing from the file are kept in the project unless --delete is given,
+since deleting a term deletes its translations in every language.`,
+	Example: "parrot push --project 123 --file ./i18n/en.json --dry-run",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format := pushFlags.format
+		if format == "" {
+			format = formatOfFile(pushFlags.file)
+		}
+
+		f, err := os.Open(pushFlags.file)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to open %s", pushFlags.file)
+		}
+		defer f.Close()
+
+		source, err := terms.Parse(format, f)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to parse %s", pushFlags.file)
+		}
+
+		httpClient, err := instantiateHTTPClient()
+		if err != nil {
+			return err
+		}
+
+		clients, err := instantiateClientResolver(httpClient)
+		if err != nil {
+			return err
+		}
+
+		client, err := clients.Client(pushFlags.project)
+		if err != nil {
+			return err
+		}
+
+		language := pushFlags.language
+		if language == "" {
+			res, err := client.ViewProject(cmd.Context(), poedit.ViewProjectRequest{ID: pushFlags.project})
+			if err != nil {
+				return errors.Wrapf(err, "Failed to view project %d", pushFlags.project)
+			}
+
+			language = res.Result.Project.ReferenceLanguage
+		}
+
+		current, err := terms.Current(cmd.Context(), client, pushFlags.project, language)
+		if err != nil {
+			return err
+		}
+
+		changes := terms.Diff(current, source)
+
+		printChanges(cmd.OutOrStdout(), changes, pushFlags.delete)
+
+		if changes.Empty() {
+			fmt.Fprintf(cmd.OutOrStdout(), "Project %d is up to date with %s\n", pushFlags.project, pushFlags.file)
+
+			return nil
+		}
+
+		if pushFlags.dryRun {
+			fmt.Fprintln(cmd.OutOrStdout(), "Dry run, nothing was pushed")
+
+			return nil
+		}
+
+		if err := terms.Push(cmd.Context(), client, pushFlags.project, current, source, changes, terms.PushOptions{
+			Language:     language,
+			Delete:       pushFlags.delete,
+			Sync:         pushFlags.sync,
+			FuzzyTrigger: pushFlags.fuzzyTrigger,
+		}); err != nil {
+			if errors.Is(err, terms.ErrDeleteNotAllowed) {
+				return errors.Wrap(err, "Syncing would delete terms, use --delete to allow it")
+			}
+
+			return err
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Pushed %s to project %d\n", pushFlags.file, pushFlags.project)
+
+		return nil
+	},
+}
+
+// nolint:gochecknoinits
+func init() {
+	pushCmd.Flags().IntVar(&pushFlags.project, "project", 0, "id of the project in poeditor")
+	pushCmd.Flags().StringVar(&pushFlags.file, "file", "", "source language file to push")
+	pushCmd.Flags().StringVar(&pushFlags.format, "format", "", "format of the file, one of "+strings.Join(terms.Formats, ", ")+" (default guessed from the file extension)")
+	pushCmd.Flags().StringVar(&pushFlags.language, "language", "", "language of the file (default the reference language of the project)")
+	pushCmd.Flags().BoolVar(&pushFlags.dryRun, "dry-run", false, "only report the changes, without pushing them")
+	pushCmd.Flags().BoolVar(&pushFlags.delete, "delete", false, "delete terms which are missing from the file, along with their translations")
+	pushCmd.Flags().BoolVar(&pushFlags.sync, "sync", false, "replace the terms of the project in a single sync call, requires --delete if terms would be removed")
+	pushCmd.Flags().BoolVar(&pushFlags.fuzzyTrigger, "fuzzy-trigger", false, "mark translations of changed terms as fuzzy in the other languages")
+
+	_ = pushCmd.MarkFlagRequired("project")
+	_ = pushCmd.MarkFlagRequired("file")
+
+	rootCmd.AddCommand(pushCmd)
+}
+
+// formatOfFile guesses the format of a file from its extension.
+func formatOfFile(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "key_value_json"
+	case ".yml", ".yaml":
+		return "yml"
+	default:
+		return strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+	}
+}
+
+func printChanges(w io.Writer, changes terms.Changes, allowDelete bool) {
+	for _, e := range changes.Added {
+		fmt.Fprintf(w, "+ %s\n", termName(e))
+	}
+
+	for _, c := range changes.Changed {
+		var what []string
+		if c.TermChanged() {
+			what = append(what, "term")
+		}
+
+		if c.ContentChanged() {
+			what = append(what, "translation")
+		}
+
+		fmt.Fprintf(w, "~ %s (%s)\n", termName(c.New), strings.Join(what, ", "))
+	}
+
+	for _, e := range changes.Removed {
+		if allowDelete {
+			fmt.Fprintf(w, "- %s\n", termName(e))
+		} else {
+			fmt.Fprintf(w, "- %s (kept, use --delete to delete)\n", termName(e))
+		}
+	}
+
+	fmt.Fprintf(w, "%d added, %d changed, %d removed\n", len(changes.Added), len(changes.Changed), len(changes.Removed))
+}
+
+func termName(e terms.Entry) string {
+	if e.Context == "" {
+		return e.Term.Term
+	}
+
+	return fmt.Sprintf("%s [%s]", e.Term.Term, e.Context)
+}
@@ -0,0 +1,272 @@
+/*
+Copyright © 2021 NAME HERE <EMAIL ADDRESS>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+	"github.com/uniwise/parrot/internal/cache"
+	"github.com/uniwise/parrot/internal/project"
+	"github.com/uniwise/parrot/internal/rest"
+)
+
+// liveConfigKeys are the keys whose changes are applied to a running server. Changes of other keys require a restart.
+var liveConfigKeys = map[string]bool{
+	confServerGrace:           true,
+	confLogLevel:              true,
+	confLogFormat:             true,
+	confCacheTTL:              true,
+	confCacheRenewalThreshold: true,
+	confCacheProjectTTL:       true,
+	confCacheWarmup:           true,
+	confAPIToken:              true,
+	confAPITokens:             true,
+	confAuthKeys:              true,
+	confRateLimitHitsRate:     true,
+	confRateLimitHitsBurst:    true,
+	confRateLimitMissesRate:   true,
+	confRateLimitMissesBurst:  true,
+}
+
+// configReloader applies changes of the config file to a running server.
+type configReloader struct {
+	ctx     context.Context
+	logger  *logrus.Logger
+	svc     *project.ServiceImpl
+	cache   cache.Cache
+	apiKeys *rest.APIKeyAuthenticator
+	limiter *rest.RateLimiter
+	mutex   *sync.Mutex
+	// values are the values of the configuration the server is running with
+	values map[string]interface{}
+}
+
+// newConfigReloader returns a reloader of the configuration of the server.
+// The service and the cache are nil in offline mode,
+// and the api key authenticator and the rate limiter are nil if access control or rate limiting is disabled.
+func newConfigReloader(ctx context.Context, logger *logrus.Logger, svc *project.ServiceImpl, c cache.Cache, apiKeys *rest.APIKeyAuthenticator, limiter *rest.RateLimiter) *configReloader {
+	return &configReloader{
+		ctx:     ctx,
+		logger:  logger,
+		svc:     svc,
+		cache:   c,
+		apiKeys: apiKeys,
+		limiter: limiter,
+		mutex:   &sync.Mutex{},
+		values:  configValues(),
+	}
+}
+
+// watch reloads the configuration whenever the config file changes.
+func (r *configReloader) watch() {
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		r.reload()
+	})
+	viper.WatchConfig()
+
+	r.logger.WithField("subsystem", "config").Infof("Watching %s for configuration changes", viper.ConfigFileUsed())
+}
+
+// reload applies the changed keys of the configuration which can be applied live, and logs an error for every other changed key.
+// An invalid configuration is rejected as a whole.
+func (r *configReloader) reload() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	l := r.logger.WithField("subsystem", "config")
+
+	invalid := false
+	for _, p := range validateConfig(r.ctx, false) {
+		if !p.warning {
+			l.WithError(p.err).Errorf("Invalid value of %s", p.key)
+			invalid = true
+		}
+	}
+
+	if invalid {
+		l.Error("Configuration change rejected, the server keeps running with the previous configuration")
+
+		return
+	}
+
+	values := configValues()
+	changed := map[string]bool{}
+
+	for _, k := range configKeys {
+		if reflect.DeepEqual(r.values[k.key], values[k.key]) {
+			continue
+		}
+
+		if !liveConfigKeys[k.key] {
+			l.Errorf("Configuration change of %s rejected, as it can not be applied without a restart", k.key)
+
+			continue
+		}
+
+		changed[k.key] = true
+	}
+
+	if len(changed) == 0 {
+		return
+	}
+
+	ignored, failed := r.apply(changed)
+
+	keys := make([]string, 0, len(changed))
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	applied := make([]string, 0, len(keys))
+	for _, key := range keys {
+		// Failed keys keep their previous value, such that they are applied again on the next change of the config file
+		if err, ok := failed[key]; ok {
+			l.WithError(err).Errorf("Failed to apply configuration change of %s", key)
+
+			continue
+		}
+
+		r.values[key] = values[key]
+
+		if reason, ok := ignored[key]; ok {
+			l.Warnf("Configuration change of %s ignored, as %s", key, reason)
+
+			continue
+		}
+
+		applied = append(applied, key)
+	}
+
+	if len(applied) > 0 {
+		l.Infof("Applied configuration change of %s", strings.Join(applied, ", "))
+	}
+}
+
+// apply applies the changed keys to the server. The changed keys which do not apply to the server,
+// such as the keys of a disabled feature, are returned along with the reason they were ignored,
+// and the changed keys which failed to be applied along with their error.
+func (r *configReloader) apply(changed map[string]bool) (map[string]string, map[string]error) {
+	ignored := map[string]string{}
+	ignore := func(reason string, keys ...string) {
+		for _, key := range keys {
+			if changed[key] {
+				ignored[key] = reason
+			}
+		}
+	}
+
+	failed := map[string]error{}
+	fail := func(err error, keys ...string) {
+		for _, key := range keys {
+			if changed[key] {
+				failed[key] = err
+			}
+		}
+	}
+
+	if changed[confLogLevel] || changed[confLogFormat] {
+		configureLogger(r.logger)
+	}
+
+	if r.cache == nil {
+		ignore("the server is offline", confCacheTTL)
+	} else if changed[confCacheTTL] {
+		r.cache.SetTTL(viper.GetDuration(confCacheTTL))
+	}
+
+	if r.apiKeys == nil {
+		ignore("access control is disabled", confAuthKeys)
+	} else if changed[confAuthKeys] {
+		keys, err := apiKeys()
+		if err == nil {
+			err = r.apiKeys.SetKeys(keys)
+		}
+
+		if err != nil {
+			fail(err, confAuthKeys)
+		}
+	}
+
+	rateLimitKeys := []string{confRateLimitHitsRate, confRateLimitHitsBurst, confRateLimitMissesRate, confRateLimitMissesBurst}
+	if r.limiter == nil {
+		ignore("rate limiting is disabled", rateLimitKeys...)
+	} else if anyChanged(changed, rateLimitKeys...) {
+		r.limiter.SetLimits(rateLimits())
+	}
+
+	if r.svc == nil {
+		ignore("the server is offline", confCacheRenewalThreshold, confCacheProjectTTL, confAPIToken, confAPITokens, confCacheWarmup)
+
+		return ignored, failed
+	}
+
+	if changed[confCacheRenewalThreshold] || changed[confCacheProjectTTL] {
+		r.svc.SetPolicies(viper.GetDuration(confCacheRenewalThreshold), viper.GetDuration(confCacheProjectTTL))
+	}
+
+	if changed[confAPIToken] || changed[confAPITokens] {
+		if resolver, ok := r.svc.Clients.(*project.TokenResolver); ok {
+			if err := resolver.SetTokens(apiTokens()); err != nil {
+				fail(err, confAPIToken, confAPITokens)
+			}
+		}
+	}
+
+	if changed[confCacheWarmup] {
+		targets, err := warmupTargets()
+		if err != nil {
+			fail(err, confCacheWarmup)
+		} else {
+			go warm(r.ctx, r.logger.WithField("subsystem", "warmup"), r.svc, targets)
+		}
+	}
+
+	return ignored, failed
+}
+
+func anyChanged(changed map[string]bool, keys ...string) bool {
+	for _, key := range keys {
+		if changed[key] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// configValues returns the current values of the configuration keys, as read by the commands.
+func configValues() map[string]interface{} {
+	values := make(map[string]interface{}, len(configKeys))
+
+	for _, k := range configKeys {
+		v := viper.Get(k.key)
+		if parsed, err := parseValue(k.kind, v); err == nil {
+			v = parsed
+		}
+
+		values[k.key] = v
+	}
+
+	return values
+}
@@ -46,10 +46,14 @@ Serve translations from poeditor instead of baking them into your frontend!
 Parrot is designed to act as a wrapper for poeditor, so you can update
 translations without rebuilding your frontend.
 `, ascii()),
+	// Errors are printed by Execute, and usage is only relevant to invalid flags
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// The errors of every command are printed once, here, without usage.
 func Execute() {
 	cobra.CheckErr(rootCmd.Execute())
 }
@@ -30,14 +30,19 @@ import (
 	"github.com/spf13/viper"
 	"github.com/uniwise/parrot/internal/cache"
 	"github.com/uniwise/parrot/internal/metrics"
+	"github.com/uniwise/parrot/internal/outbound"
 	"github.com/uniwise/parrot/internal/project"
 	"github.com/uniwise/parrot/internal/rest"
+	"github.com/uniwise/parrot/internal/snapshot"
 	"github.com/uniwise/parrot/pkg/poedit"
 )
 
 const (
-	confServerPort  = "server.port"
-	confServerGrace = "server.gracePeriod"
+	confServerPort           = "server.port"
+	confServerGrace          = "server.gracePeriod"
+	confServerTrustedProxies = "server.trustedProxies"
+
+	confConfigWatch = "config.watch"
 
 	confLogLevel  = "log.level"
 	confLogFormat = "log.format"
@@ -45,6 +50,8 @@ const (
 	confCacheType                  = "cache.type"
 	confCacheTTL                   = "cache.ttl"
 	confCacheRenewalThreshold      = "cache.renewalThreshold"
+	confCacheProjectTTL            = "cache.projectTTL"
+	confCacheWarmup                = "cache.warmup"
 	confCacheFSDir                 = "cache.filesystem.dir"
 	confCacheRedisMode             = "cache.redis.mode"
 	confCacheRedisAddress          = "cache.redis.address"
@@ -56,11 +63,51 @@ const (
 	confCacheRedisSentinelAddress  = "cache.redis.sentinel.addresses"
 	confCacheRedisSentinelPassword = "cache.redis.sentinel.password" //nolint:gosec
 
+	confSnapshotPath    = "snapshot.path"
+	confSnapshotOffline = "snapshot.offline"
+	confSnapshotSeed    = "snapshot.seed"
+
+	confBackupDir      = "backup.dir"
+	confBackupInterval = "backup.interval"
+	confBackupKeep     = "backup.keep"
+	confBackupMaxAge   = "backup.maxAge"
+
 	confPrometheusEnabled = "prometheus.enabled"
 	confPrometheusPath    = "prometheus.path"
 	confPrometheusPort    = "prometheus.port"
 
-	confAPIToken = "api.token"
+	confAPIToken                = "api.token"
+	confAPITokens               = "api.tokens"
+	confAPIMaxConcurrentFetches = "api.maxConcurrentFetches"
+	confAPIMaxQueuedFetches     = "api.maxQueuedFetches"
+	confAPIRetries              = "api.retries"
+	confAPIRetryWait            = "api.retryWait"
+	confAPIRetryMaxWait         = "api.retryMaxWait"
+	confAPIBreakerThreshold     = "api.breaker.threshold"
+	confAPIBreakerCooldown      = "api.breaker.cooldown"
+
+	confOutboundConnectTimeout  = "outbound.connectTimeout"
+	confOutboundReadTimeout     = "outbound.readTimeout"
+	confOutboundTimeout         = "outbound.timeout"
+	confOutboundProxy           = "outbound.proxy"
+	confOutboundCAFile          = "outbound.caFile"
+	confOutboundMaxResponseSize = "outbound.maxResponseSize"
+	confOutboundAllowedSchemes  = "outbound.download.allowedSchemes"
+	confOutboundAllowedHosts    = "outbound.download.allowedHosts"
+
+	confAuthEnabled            = "auth.enabled"
+	confAuthKeys               = "auth.keys"
+	confAuthJWTJWKS            = "auth.jwt.jwks"
+	confAuthJWTRefreshInterval = "auth.jwt.refreshInterval"
+	confAuthJWTIssuer          = "auth.jwt.issuer"
+	confAuthJWTAudience        = "auth.jwt.audience"
+	confAuthJWTProjectsClaim   = "auth.jwt.projectsClaim"
+
+	confRateLimitEnabled     = "rateLimit.enabled"
+	confRateLimitHitsRate    = "rateLimit.hits.rate"
+	confRateLimitHitsBurst   = "rateLimit.hits.burst"
+	confRateLimitMissesRate  = "rateLimit.misses.rate"
+	confRateLimitMissesBurst = "rateLimit.misses.burst"
 )
 
 // serveCmd represents the serve command
@@ -69,20 +116,59 @@ var serveCmd = &cobra.Command{
 	Short: "Start the parrot caching server",
 	Long: `Start the parrot caching server.
 This will take care of serving your translations,
-by caching exports from poeditor`,
+by caching exports from poeditor.
+
+With snapshot.offline, translations are served from a snapshot
+made by "parrot export" instead, without contacting poeditor.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		logger := instantiateLogger()
 
-		cacheInstance, err := instantiateCache(logger.WithField("subsystem", "cache"))
+		bgCtx, bgCancel := context.WithCancel(context.Background())
+		defer bgCancel()
+
+		var svc project.Service
+		var cachingSvc *project.ServiceImpl
+		var cacheInstance cache.Cache
+		var err error
+
+		if viper.GetBool(confSnapshotOffline) {
+			svc, err = instantiateOfflineService(logger)
+		} else {
+			cacheInstance, cachingSvc, err = instantiateCachingService(bgCtx, logger)
+			svc = cachingSvc
+		}
+
 		if err != nil {
 			logger.Fatal(err)
 		}
 
-		cli := poedit.NewClient(viper.GetString(confAPIToken), http.DefaultClient)
+		// Offline, poeditor is not contacted, so there is nothing to back up
+		if interval := viper.GetDuration(confBackupInterval); interval > 0 && !viper.GetBool(confSnapshotOffline) {
+			backuper, err := instantiateBackuper()
+			if err != nil {
+				logger.Fatal(err)
+			}
+
+			go backuper.RunPeriodically(bgCtx, interval, logger.WithField("subsystem", "backup"))
+		}
+
+		access, keyAuthenticator, err := instantiateAccessControl(bgCtx, logger.WithField("subsystem", "access"))
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		limiter := instantiateRateLimiter(logger.WithField("subsystem", "ratelimit"))
+
+		if viper.GetBool(confConfigWatch) && viper.ConfigFileUsed() != "" {
+			newConfigReloader(bgCtx, logger, cachingSvc, cacheInstance, keyAuthenticator, limiter).watch()
+		}
 
-		svc := project.NewService(cli, cacheInstance, viper.GetDuration(confCacheRenewalThreshold), logrus.NewEntry(logger))
+		server, err := rest.NewServer(logrus.NewEntry(logger), svc, viper.GetBool(confPrometheusEnabled), access, limiter)
+		if err != nil {
+			logger.Fatal(err)
+		}
 
-		server, err := rest.NewServer(logrus.NewEntry(logger), svc, viper.GetBool(confPrometheusEnabled))
+		server.Echo.IPExtractor, err = rest.IPExtractor(viper.GetStringSlice(confServerTrustedProxies))
 		if err != nil {
 			logger.Fatal(err)
 		}
@@ -124,17 +210,52 @@ func init() {
 	viper.SetDefault(confServerPort, 80)
 	viper.SetDefault(confServerGrace, time.Second*10)
 
+	viper.SetDefault(confConfigWatch, true)
+
 	viper.SetDefault(confLogLevel, "info")
 	viper.SetDefault(confLogFormat, "json")
 
 	viper.SetDefault(confCacheType, "filesystem")
 	viper.SetDefault(confCacheTTL, time.Hour)
 	viper.SetDefault(confCacheRenewalThreshold, time.Minute*30)
+	viper.SetDefault(confCacheProjectTTL, time.Minute*5)
 	viper.SetDefault(confCacheFSDir, path.Join(cDir, "parrot"))
 	viper.SetDefault(confCacheRedisMode, "single")
 	viper.SetDefault(confCacheRedisMaxRetries, -1)
 	viper.SetDefault(confCacheRedisDB, 1)
 
+	viper.SetDefault(confSnapshotOffline, false)
+	viper.SetDefault(confSnapshotSeed, false)
+
+	viper.SetDefault(confBackupInterval, time.Duration(0))
+	viper.SetDefault(confBackupKeep, 7)
+	viper.SetDefault(confBackupMaxAge, time.Duration(0))
+
+	viper.SetDefault(confAPIMaxConcurrentFetches, 10)
+	viper.SetDefault(confAPIMaxQueuedFetches, 100)
+	viper.SetDefault(confAPIRetries, 2)
+	viper.SetDefault(confAPIRetryWait, time.Millisecond*200)
+	viper.SetDefault(confAPIRetryMaxWait, time.Second*2)
+	viper.SetDefault(confAPIBreakerThreshold, 5)
+	viper.SetDefault(confAPIBreakerCooldown, time.Second*30)
+
+	viper.SetDefault(confOutboundConnectTimeout, time.Second*5)
+	viper.SetDefault(confOutboundReadTimeout, time.Second*30)
+	viper.SetDefault(confOutboundTimeout, time.Minute)
+	viper.SetDefault(confOutboundMaxResponseSize, 50<<20)
+	viper.SetDefault(confOutboundAllowedSchemes, []string{"https"})
+	viper.SetDefault(confOutboundAllowedHosts, []string{"poeditor.com", "*.poeditor.com"})
+
+	viper.SetDefault(confAuthEnabled, false)
+	viper.SetDefault(confAuthJWTRefreshInterval, time.Minute*15)
+	viper.SetDefault(confAuthJWTProjectsClaim, "parrot_projects")
+
+	viper.SetDefault(confRateLimitEnabled, false)
+	viper.SetDefault(confRateLimitHitsRate, 20)
+	viper.SetDefault(confRateLimitHitsBurst, 40)
+	viper.SetDefault(confRateLimitMissesRate, 1)
+	viper.SetDefault(confRateLimitMissesBurst, 10)
+
 	viper.SetDefault(confPrometheusEnabled, true)
 	viper.SetDefault(confPrometheusPort, 9090)
 	viper.SetDefault(confPrometheusPath, "/metrics")
@@ -144,7 +265,13 @@ func init() {
 
 func instantiateLogger() *logrus.Logger {
 	logger := logrus.New()
+	configureLogger(logger)
+
+	return logger
+}
 
+// configureLogger applies the configured level and format to the logger.
+func configureLogger(logger *logrus.Logger) {
 	lvl, err := logrus.ParseLevel(viper.GetString(confLogLevel))
 	if err != nil {
 		logger.WithError(err).Warnf("Could not parse log level '%s' defaulting to INFO", viper.GetString(confLogLevel))
@@ -161,7 +288,6 @@ func instantiateLogger() *logrus.Logger {
 		logger.Warnf("Did not understand log format '%s'. Defaulting to json format", viper.GetString(confLogFormat))
 		logger.SetFormatter(&logrus.JSONFormatter{})
 	}
-	return logger
 }
 
 func instantiateCache(l *logrus.Entry) (cache.Cache, error) {
@@ -212,3 +338,250 @@ func instantiateRedisCache() (*cache.RedisCache, error) {
 func instantiateFilesystemCache() (*cache.FilesystemCache, error) {
 	return cache.NewFilesystemCache(viper.GetString(confCacheFSDir), viper.GetDuration(confCacheTTL))
 }
+
+func instantiateHTTPClient() (*http.Client, error) {
+	return outbound.NewHTTPClient(outbound.Config{
+		ConnectTimeout:  viper.GetDuration(confOutboundConnectTimeout),
+		ReadTimeout:     viper.GetDuration(confOutboundReadTimeout),
+		Timeout:         viper.GetDuration(confOutboundTimeout),
+		Proxy:           viper.GetString(confOutboundProxy),
+		CAFile:          viper.GetString(confOutboundCAFile),
+		MaxResponseSize: viper.GetInt64(confOutboundMaxResponseSize),
+	})
+}
+
+func instantiateDownloader(httpClient *http.Client) *outbound.Downloader {
+	return outbound.NewDownloader(
+		httpClient,
+		viper.GetStringSlice(confOutboundAllowedSchemes),
+		viper.GetStringSlice(confOutboundAllowedHosts),
+	)
+}
+
+func instantiateService(logger *logrus.Logger, cacheInstance cache.Cache, httpClient *http.Client) (*project.ServiceImpl, error) {
+	clients, err := instantiateClientResolver(httpClient)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchQueue := project.NewFetchQueue(viper.GetInt(confAPIMaxConcurrentFetches), viper.GetInt(confAPIMaxQueuedFetches))
+
+	return project.NewService(clients, cacheInstance, fetchQueue, instantiateDownloader(httpClient), viper.GetDuration(confCacheRenewalThreshold), viper.GetDuration(confCacheProjectTTL), logrus.NewEntry(logger)), nil
+}
+
+// instantiateCachingService returns the service serving translations from poeditor through the cache.
+// The cache is seeded from the configured snapshot before serving, and warmed in the background until the context is done.
+func instantiateCachingService(ctx context.Context, logger *logrus.Logger) (cache.Cache, *project.ServiceImpl, error) {
+	cacheInstance, err := instantiateCache(logger.WithField("subsystem", "cache"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	httpClient, err := instantiateHTTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	svc, err := instantiateService(logger, cacheInstance, httpClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if snapshotPath := viper.GetString(confSnapshotPath); snapshotPath != "" && viper.GetBool(confSnapshotSeed) {
+		snap, err := snapshot.Load(snapshotPath)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		seeded, err := snapshot.Seed(ctx, cacheInstance, snap)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "Failed to seed cache")
+		}
+
+		logger.Infof("Seeded cache with %d translations from snapshot %s", seeded, snapshotPath)
+	}
+
+	targets, err := warmupTargets()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	go warm(ctx, logger.WithField("subsystem", "warmup"), svc, targets)
+
+	return cacheInstance, svc, nil
+}
+
+// instantiateOfflineService returns the service serving translations from the configured snapshot, without poeditor.
+func instantiateOfflineService(logger *logrus.Logger) (*snapshot.Service, error) {
+	snapshotPath := viper.GetString(confSnapshotPath)
+	if snapshotPath == "" {
+		return nil, errors.Errorf("Offline mode requires %s", confSnapshotPath)
+	}
+
+	snap, err := snapshot.Load(snapshotPath)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("Serving %d translations of snapshot %s offline", len(snap.Translations()), snapshotPath)
+
+	return snapshot.NewService(snap, viper.GetDuration(confCacheTTL)), nil
+}
+
+// warmupTargets returns the configured warmup targets, which must each have a project and formats.
+func warmupTargets() ([]project.WarmupTarget, error) {
+	var targets []project.WarmupTarget
+	if err := viper.UnmarshalKey(confCacheWarmup, &targets); err != nil {
+		return nil, errors.Wrap(err, "Failed to read cache warmup")
+	}
+
+	if err := checkWarmupTargets(targets); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// checkWarmupTargets rejects targets without a project or formats, for which nothing would be warmed.
+func checkWarmupTargets(targets []project.WarmupTarget) error {
+	for i, t := range targets {
+		if t.Project == 0 || len(t.Formats) == 0 {
+			return errors.Errorf("Warmup target %d needs a project and formats", i+1)
+		}
+
+		for _, f := range t.Formats {
+			if f == "" {
+				return errors.Errorf("Warmup target %d has an empty format", i+1)
+			}
+		}
+	}
+
+	return nil
+}
+
+// warm fetches the warmup targets into the cache, logging the translations which failed.
+func warm(ctx context.Context, l *logrus.Entry, svc *project.ServiceImpl, targets []project.WarmupTarget) {
+	if len(targets) == 0 {
+		return
+	}
+
+	fetched := 0
+
+	for _, r := range svc.Warm(ctx, targets) {
+		if r.Err != nil {
+			l.WithError(r.Err).Errorf("Failed to warm language %s format %s for project %d", r.LanguageCode, r.Format, r.ProjectID)
+
+			continue
+		}
+
+		if r.Fetched {
+			fetched++
+		}
+	}
+
+	l.Infof("Warmed cache, fetched %d translations", fetched)
+}
+
+// instantiateClientResolver returns a resolver of poeditor clients from the configured api tokens.
+func instantiateClientResolver(httpClient *http.Client) (*project.TokenResolver, error) {
+	return project.NewTokenResolver(apiTokens(), httpClient,
+		poedit.WithRetries(
+			viper.GetInt(confAPIRetries),
+			viper.GetDuration(confAPIRetryWait),
+			viper.GetDuration(confAPIRetryMaxWait),
+		),
+		poedit.WithCircuitBreaker(
+			viper.GetInt(confAPIBreakerThreshold),
+			viper.GetDuration(confAPIBreakerCooldown),
+		),
+	)
+}
+
+// apiTokens returns the configured map of api tokens.
+// The single api token is used as the default token, unless the token map has a default of its own.
+func apiTokens() map[string]string {
+	tokens := viper.GetStringMapString(confAPITokens)
+
+	if token := viper.GetString(confAPIToken); token != "" {
+		if _, ok := tokens["default"]; !ok {
+			tokens["default"] = token
+		}
+	}
+
+	return tokens
+}
+
+// instantiateAccessControl returns the access control of the api, along with its api key authenticator,
+// or nil if access is unrestricted. Keys of a configured jwks are refreshed in the background until the context is done.
+func instantiateAccessControl(ctx context.Context, l *logrus.Entry) (*rest.AccessControl, *rest.APIKeyAuthenticator, error) {
+	if !viper.GetBool(confAuthEnabled) {
+		return nil, nil, nil
+	}
+
+	var authenticators []rest.Authenticator
+
+	if source := viper.GetString(confAuthJWTJWKS); source != "" {
+		jwks, err := rest.NewJWKS(source)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		jwtAuthenticator, err := rest.NewJWTAuthenticator(
+			jwks,
+			viper.GetString(confAuthJWTIssuer),
+			viper.GetString(confAuthJWTAudience),
+			viper.GetString(confAuthJWTProjectsClaim),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		go jwks.RefreshPeriodically(ctx, viper.GetDuration(confAuthJWTRefreshInterval), l)
+
+		authenticators = append(authenticators, jwtAuthenticator)
+	}
+
+	keys, err := apiKeys()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apiKeyAuthenticator, err := rest.NewAPIKeyAuthenticator(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	authenticators = append(authenticators, apiKeyAuthenticator)
+
+	return rest.NewAccessControl(l, authenticators...), apiKeyAuthenticator, nil
+}
+
+func apiKeys() ([]rest.APIKey, error) {
+	var keys []rest.APIKey
+	if err := viper.UnmarshalKey(confAuthKeys, &keys); err != nil {
+		return nil, errors.Wrap(err, "Failed to read api keys")
+	}
+
+	return keys, nil
+}
+
+// instantiateRateLimiter returns the rate limiter of the api, or nil if rate limiting is disabled.
+func instantiateRateLimiter(l *logrus.Entry) *rest.RateLimiter {
+	if !viper.GetBool(confRateLimitEnabled) {
+		return nil
+	}
+
+	hits, misses := rateLimits()
+
+	return rest.NewRateLimiter(l, hits, misses)
+}
+
+func rateLimits() (hits, misses rest.RateLimit) {
+	return rest.RateLimit{
+			Rate:  viper.GetFloat64(confRateLimitHitsRate),
+			Burst: viper.GetInt(confRateLimitHitsBurst),
+		}, rest.RateLimit{
+			Rate:  viper.GetFloat64(confRateLimitMissesRate),
+			Burst: viper.GetInt(confRateLimitMissesBurst),
+		}
+}
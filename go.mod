@@ -4,10 +4,11 @@ go 1.16
 
 require (
 	github.com/AppsFlyer/go-sundheit v0.5.0
+	github.com/fsnotify/fsnotify v1.6.0
 	github.com/go-playground/universal-translator v0.17.0 // indirect
 	github.com/go-playground/validator v9.31.0+incompatible
-	github.com/go-redis/cache/v8 v8.4.4
 	github.com/go-redis/redis/v8 v8.11.5
+	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/golang/mock v1.6.0
 	github.com/johngb/langreg v0.0.0-20150123211413-5c6abc6d19d2
 	github.com/joho/godotenv v1.4.0
@@ -19,10 +20,13 @@ require (
 	github.com/prometheus/client_golang v1.14.0
 	github.com/sirupsen/logrus v1.9.0
 	github.com/smartystreets/goconvey v1.6.4 // indirect
+	github.com/spf13/cast v1.5.0
 	github.com/spf13/cobra v1.6.1
 	github.com/spf13/viper v1.14.0
 	golang.org/x/sync v0.1.0
 	golang.org/x/sys v0.3.0
+	golang.org/x/time v0.2.0
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/resty.v1 v1.12.0
+	gopkg.in/yaml.v2 v2.4.0
 )
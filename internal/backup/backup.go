@@ -0,0 +1,326 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/uniwise/parrot/internal/outbound"
+	"github.com/uniwise/parrot/internal/snapshot"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+const (
+	// Format is the poeditor format of backups, which keeps the context, plural, reference and comment of every term.
+	Format = "json"
+	// TimeLayout is the layout of the names of backup directories, which are named by the time of the backup.
+	TimeLayout = "20060102T150405Z"
+	// IncompleteFile marks a backup in which some projects failed to be backed up, and lists their errors.
+	IncompleteFile = "INCOMPLETE"
+)
+
+// Accounts returns a poeditor client for every account to back up.
+type Accounts interface {
+	Clients() ([]poedit.Client, error)
+}
+
+// Retention is the rules for which backups to keep. The latest backup, and the latest complete backup, are always kept.
+type Retention struct {
+	// Keep is the number of backups to keep, or 0 to keep any number.
+	Keep int
+	// MaxAge is the age of backups to keep, or 0 to keep backups of any age.
+	MaxAge time.Duration
+}
+
+// Backup is a backup directory, with a snapshot of every project of the accounts below it.
+type Backup struct {
+	Dir  string
+	Time time.Time
+	// Projects are the manifests of the backed up projects, only set for backups made by Run.
+	Projects []*snapshot.Manifest
+	// Failed are the errors of the projects which failed to be backed up, by project id, only set for backups made by Run.
+	Failed map[int]error
+	// FailedAccounts are the errors of the accounts whose projects could not be listed, only set for backups made by Run.
+	FailedAccounts []error
+	// Incomplete is whether some projects or accounts failed to be backed up, such that they are missing from the backup.
+	Incomplete bool
+	// Pruned are the directories of the older backups removed by Run.
+	Pruned []string
+}
+
+// Backuper backs up every project of the accounts into timestamped directories, pruning old backups by the retention.
+type Backuper struct {
+	accounts   Accounts
+	downloader *outbound.Downloader
+	dir        string
+	retention  Retention
+}
+
+func NewBackuper(accounts Accounts, downloader *outbound.Downloader, dir string, retention Retention) *Backuper {
+	return &Backuper{
+		accounts:   accounts,
+		downloader: downloader,
+		dir:        dir,
+		retention:  retention,
+	}
+}
+
+// Run exports every language of every project of the accounts into a new backup directory, and prunes older backups.
+// All terms are exported, whether translated or not. The backup is written to a temporary directory,
+// which is moved into place once complete, such that a failed backup never replaces a complete one.
+// A project which fails to be backed up does not fail the others, but is recorded in Failed, and the backup is marked incomplete.
+// Likewise, an account whose projects can not be listed is recorded in FailedAccounts. Run only fails if no project could be backed up.
+func (b *Backuper) Run(ctx context.Context) (*Backup, error) {
+	clients, err := b.accounts.Clients()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(clients) == 0 {
+		return nil, errors.New("No poeditor api tokens to back up projects with")
+	}
+
+	if err := os.MkdirAll(b.dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "Failed to create backup directory '%s'", b.dir)
+	}
+
+	tmp, err := os.MkdirTemp(b.dir, ".backup-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create temporary backup directory")
+	}
+	defer os.RemoveAll(tmp)
+
+	now := time.Now().UTC()
+	backup := &Backup{
+		Dir:    filepath.Join(b.dir, now.Format(TimeLayout)),
+		Time:   now,
+		Failed: map[int]error{},
+	}
+
+	// Several tokens may belong to the same account, so projects are only backed up once
+	seen := map[int]bool{}
+
+	for _, client := range clients {
+		res, err := client.ListProjects(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, errors.Wrap(err, "Failed to list projects")
+			}
+
+			backup.FailedAccounts = append(backup.FailedAccounts, errors.Wrap(err, "Failed to list projects"))
+
+			continue
+		}
+
+		exporter := snapshot.NewExporter(client, b.downloader, snapshot.WithFilters())
+
+		for _, p := range res.Result.Projects {
+			projectID := int(p.ID)
+			if seen[projectID] {
+				continue
+			}
+			seen[projectID] = true
+
+			projectDir := filepath.Join(tmp, strconv.Itoa(projectID))
+
+			manifest, err := exporter.Export(ctx, projectID, nil, Format, projectDir)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil, errors.Wrapf(err, "Failed to back up project %d", projectID)
+				}
+
+				// Leave no partial export of the project in the backup
+				if rerr := os.RemoveAll(projectDir); rerr != nil {
+					return nil, errors.Wrapf(rerr, "Failed to remove partial backup of project %d", projectID)
+				}
+
+				backup.Failed[projectID] = err
+
+				continue
+			}
+
+			backup.Projects = append(backup.Projects, manifest)
+		}
+	}
+
+	backup.Incomplete = len(backup.Failed) > 0 || len(backup.FailedAccounts) > 0
+
+	if len(backup.Projects) == 0 && backup.Incomplete {
+		return nil, errors.Errorf("Failed to back up any project:\n%s", Failures(backup))
+	}
+
+	sort.Slice(backup.Projects, func(i, j int) bool {
+		return backup.Projects[i].ProjectID < backup.Projects[j].ProjectID
+	})
+
+	if backup.Incomplete {
+		if err := os.WriteFile(filepath.Join(tmp, IncompleteFile), []byte(Failures(backup)+"\n"), 0o644); err != nil {
+			return nil, errors.Wrap(err, "Failed to mark backup as incomplete")
+		}
+	}
+
+	if err := os.Chmod(tmp, 0o755); err != nil {
+		return nil, errors.Wrap(err, "Failed to set backup directory permissions")
+	}
+
+	if err := os.Rename(tmp, backup.Dir); err != nil {
+		return nil, errors.Wrap(err, "Failed to move backup into place")
+	}
+
+	backup.Pruned, err = Prune(b.dir, b.retention)
+	if err != nil {
+		return backup, err
+	}
+
+	return backup, nil
+}
+
+// RunPeriodically makes a backup every interval until the context is done.
+// The first backup is made once the latest existing backup is an interval old, such that restarts do not cause extra backups.
+func (b *Backuper) RunPeriodically(ctx context.Context, interval time.Duration, l *logrus.Entry) {
+	wait := time.Duration(0)
+	if backups, err := List(b.dir); err == nil && len(backups) > 0 {
+		wait = interval - time.Since(backups[0].Time)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			backup, err := b.Run(ctx)
+			if err != nil {
+				l.WithError(err).Error("Failed to back up projects")
+			} else {
+				for _, err := range backup.FailedAccounts {
+					l.WithError(err).Errorf("Failed to back up the projects of an account, backup %s is incomplete", backup.Dir)
+				}
+
+				for _, projectID := range sortedKeys(backup.Failed) {
+					l.WithError(backup.Failed[projectID]).Errorf("Failed to back up project %d, backup %s is incomplete", projectID, backup.Dir)
+				}
+
+				l.Infof("Backed up %d projects to %s, pruned %d old backups", len(backup.Projects), backup.Dir, len(backup.Pruned))
+			}
+
+			timer.Reset(interval)
+		}
+	}
+}
+
+// List returns the backups in a directory, newest first. Other files and directories are ignored.
+func List(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read backup directory '%s'", dir)
+	}
+
+	backups := []Backup{}
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		t, err := time.Parse(TimeLayout, e.Name())
+		if err != nil {
+			continue
+		}
+
+		backupDir := filepath.Join(dir, e.Name())
+
+		_, err = os.Stat(filepath.Join(backupDir, IncompleteFile))
+
+		backups = append(backups, Backup{
+			Dir:        backupDir,
+			Time:       t,
+			Incomplete: err == nil,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Time.After(backups[j].Time)
+	})
+
+	return backups, nil
+}
+
+// Prune removes the backups in a directory which are not kept by the retention, and returns their directories.
+func Prune(dir string, retention Retention) ([]string, error) {
+	backups, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pruned := []string{}
+
+	// The latest complete backup is kept regardless of the retention, such that incomplete backups never replace it
+	latestComplete := -1
+	for i := range backups {
+		if !backups[i].Incomplete {
+			latestComplete = i
+
+			break
+		}
+	}
+
+	// The latest backup is kept regardless of the retention
+	for i := 1; i < len(backups); i++ {
+		if i == latestComplete {
+			continue
+		}
+
+		tooMany := retention.Keep > 0 && i >= retention.Keep
+		tooOld := retention.MaxAge > 0 && time.Since(backups[i].Time) > retention.MaxAge
+
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err := os.RemoveAll(backups[i].Dir); err != nil {
+			return pruned, errors.Wrapf(err, "Failed to remove backup '%s'", backups[i].Dir)
+		}
+
+		pruned = append(pruned, backups[i].Dir)
+	}
+
+	return pruned, nil
+}
+
+// Failures describes the errors of the failed accounts and projects of a backup, one per line.
+func Failures(backup *Backup) string {
+	lines := []string{}
+
+	for _, err := range backup.FailedAccounts {
+		lines = append(lines, fmt.Sprintf("account: %v", err))
+	}
+
+	for _, projectID := range sortedKeys(backup.Failed) {
+		lines = append(lines, fmt.Sprintf("project %d: %v", projectID, backup.Failed[projectID]))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func sortedKeys(m map[int]error) []int {
+	keys := make([]int, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+
+	return keys
+}
@@ -0,0 +1,242 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/uniwise/parrot/internal/outbound"
+	"github.com/uniwise/parrot/pkg/poedit"
+	"github.com/uniwise/parrot/pkg/poedit/poedittest"
+)
+
+// writeBackups creates a backup directory for every age, marked incomplete if the age is among the incomplete ones,
+// and returns the directories by age.
+func writeBackups(t *testing.T, dir string, now time.Time, ages []time.Duration, incomplete map[time.Duration]bool) map[time.Duration]string {
+	t.Helper()
+
+	dirs := map[time.Duration]string{}
+
+	for _, age := range ages {
+		backupDir := filepath.Join(dir, now.Add(-age).Format(TimeLayout))
+		if err := os.MkdirAll(backupDir, 0o755); err != nil {
+			t.Fatalf("Failed to create backup: %v", err)
+		}
+
+		if incomplete[age] {
+			if err := os.WriteFile(filepath.Join(backupDir, IncompleteFile), []byte("project 1: failed\n"), 0o644); err != nil {
+				t.Fatalf("Failed to mark backup as incomplete: %v", err)
+			}
+		}
+
+		dirs[age] = backupDir
+	}
+
+	return dirs
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	dirs := writeBackups(t, dir, now, []time.Duration{time.Hour * 2, 0, time.Hour}, map[time.Duration]bool{time.Hour: true})
+
+	if err := os.MkdirAll(filepath.Join(dir, ".backup-123"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, now.Format(TimeLayout)+".txt"), nil, 0o644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	backups, err := List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	want := []Backup{
+		{Dir: dirs[0], Time: now},
+		{Dir: dirs[time.Hour], Time: now.Add(-time.Hour), Incomplete: true},
+		{Dir: dirs[time.Hour*2], Time: now.Add(-time.Hour * 2)},
+	}
+
+	if !reflect.DeepEqual(backups, want) {
+		t.Errorf("List() = %+v, want %+v", backups, want)
+	}
+
+	if backups, err := List(filepath.Join(dir, "missing")); err != nil || len(backups) != 0 {
+		t.Errorf("List() of a missing directory = %v, %v, want no backups", backups, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	const day = time.Hour * 24
+
+	tests := []struct {
+		name       string
+		ages       []time.Duration
+		incomplete map[time.Duration]bool
+		retention  Retention
+		kept       []time.Duration
+	}{
+		{
+			name:      "keep any",
+			ages:      []time.Duration{0, day, day * 2},
+			retention: Retention{},
+			kept:      []time.Duration{0, day, day * 2},
+		},
+		{
+			name:      "keep count",
+			ages:      []time.Duration{0, day, day * 2, day * 3},
+			retention: Retention{Keep: 2},
+			kept:      []time.Duration{0, day},
+		},
+		{
+			name:      "max age",
+			ages:      []time.Duration{0, day, day * 3},
+			retention: Retention{MaxAge: day * 2},
+			kept:      []time.Duration{0, day},
+		},
+		{
+			name:      "latest backup older than max age",
+			ages:      []time.Duration{day * 3, day * 4},
+			retention: Retention{MaxAge: day},
+			kept:      []time.Duration{day * 3},
+		},
+		{
+			name:       "latest complete backup beyond the count",
+			ages:       []time.Duration{0, day, day * 2, day * 3},
+			incomplete: map[time.Duration]bool{0: true, day: true},
+			retention:  Retention{Keep: 1},
+			kept:       []time.Duration{0, day * 2},
+		},
+		{
+			name:       "latest complete backup older than max age",
+			ages:       []time.Duration{0, day * 3},
+			incomplete: map[time.Duration]bool{0: true},
+			retention:  Retention{MaxAge: day},
+			kept:       []time.Duration{0, day * 3},
+		},
+		{
+			name:       "only incomplete backups",
+			ages:       []time.Duration{0, day, day * 2},
+			incomplete: map[time.Duration]bool{0: true, day: true, day * 2: true},
+			retention:  Retention{Keep: 1},
+			kept:       []time.Duration{0},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dirs := writeBackups(t, dir, time.Now().UTC(), tt.ages, tt.incomplete)
+
+			pruned, err := Prune(dir, tt.retention)
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+
+			kept := map[time.Duration]bool{}
+			for _, age := range tt.kept {
+				kept[age] = true
+			}
+
+			for age, backupDir := range dirs {
+				_, err := os.Stat(backupDir)
+				if exists := err == nil; exists != kept[age] {
+					t.Errorf("Backup of age %v exists = %v, want %v", age, exists, kept[age])
+				}
+			}
+
+			if len(pruned) != len(tt.ages)-len(tt.kept) {
+				t.Errorf("Prune() = %v, want %d pruned", pruned, len(tt.ages)-len(tt.kept))
+			}
+		})
+	}
+}
+
+type testAccounts []poedit.Client
+
+func (a testAccounts) Clients() ([]poedit.Client, error) {
+	return a, nil
+}
+
+func newTestClient(s *poedittest.Server) poedit.Client {
+	return poedit.NewClient("token", s.Client(), poedit.WithHostURL(s.URL), poedit.WithRetries(0, 0, 0))
+}
+
+func TestRun(t *testing.T) {
+	ok := poedittest.NewServer()
+	defer ok.Close()
+
+	ok.AddProject(1, "Website", "en")
+	ok.AddLanguage(1, "da")
+	ok.SetTranslation(1, "da", poedit.TermKey{Term: "hello"}, poedit.TranslationContent{Singular: "Hej"})
+
+	failing := poedittest.NewServer()
+	defer failing.Close()
+
+	failing.Fail("/v2/projects/list", "4011", "Invalid API Token")
+
+	tests := []struct {
+		name           string
+		accounts       testAccounts
+		wantErr        bool
+		wantIncomplete bool
+	}{
+		{name: "complete", accounts: testAccounts{newTestClient(ok)}},
+		{name: "failing account", accounts: testAccounts{newTestClient(failing), newTestClient(ok)}, wantIncomplete: true},
+		{name: "only failing accounts", accounts: testAccounts{newTestClient(failing)}, wantErr: true},
+		{name: "no accounts", accounts: testAccounts{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			downloader := outbound.NewDownloader(ok.Client(), []string{"https"}, []string{"127.0.0.1"})
+
+			backup, err := NewBackuper(tt.accounts, downloader, dir, Retention{}).Run(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Run() = %+v, want an error", backup)
+				}
+
+				if backups, _ := List(dir); len(backups) != 0 {
+					t.Errorf("Run() left backups %+v", backups)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+
+			if len(backup.Projects) != 1 || backup.Projects[0].ProjectID != 1 {
+				t.Fatalf("Run() backed up %+v, want project 1", backup.Projects)
+			}
+
+			if backup.Incomplete != tt.wantIncomplete || len(backup.FailedAccounts) > 0 != tt.wantIncomplete {
+				t.Errorf("Run() incomplete = %v, failed accounts %v, want incomplete %v", backup.Incomplete, backup.FailedAccounts, tt.wantIncomplete)
+			}
+
+			backups, err := List(dir)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			if len(backups) != 1 || backups[0].Dir != backup.Dir || backups[0].Incomplete != tt.wantIncomplete {
+				t.Errorf("List() = %+v, want the backup of Run", backups)
+			}
+
+			if _, err := os.Stat(filepath.Join(backup.Dir, "1", "da.json")); err != nil {
+				t.Errorf("Translation of project 1 missing from backup: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/internal/snapshot"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// RestoreOptions controls how a project is restored from a backup.
+type RestoreOptions struct {
+	// Languages are the languages to restore, or every language of the backup if empty.
+	Languages []string
+	// Sync deletes the terms of the project which are not in the backup.
+	Sync bool
+}
+
+// RestoreResult is the outcome of uploading a language of a backup.
+type RestoreResult struct {
+	LanguageCode        string
+	TermsAdded          int64
+	TermsDeleted        int64
+	TranslationsAdded   int64
+	TranslationsUpdated int64
+}
+
+// ProjectDir returns the directory of the backup of a project, within a backup directory.
+func ProjectDir(backupDir string, projectID int) string {
+	return filepath.Join(backupDir, strconv.Itoa(projectID))
+}
+
+// Restore uploads the languages of the backup of a project in projectDir to a project in poeditor,
+// adding the terms missing from the project and overwriting its translations.
+// The languages must exist in the project. The files are verified against the manifest of the backup before anything is uploaded.
+func Restore(ctx context.Context, client poedit.Client, projectDir string, projectID int, opts RestoreOptions) ([]RestoreResult, error) {
+	manifest, err := snapshot.ReadManifest(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	languages := opts.Languages
+	if len(languages) == 0 {
+		for code := range manifest.Languages {
+			languages = append(languages, code)
+		}
+		sort.Strings(languages)
+	}
+
+	files := map[string][]byte{}
+
+	for _, code := range languages {
+		l, ok := manifest.Languages[code]
+		if !ok {
+			return nil, errors.Errorf("Language '%s' is not in the backup of project %d", code, manifest.ProjectID)
+		}
+
+		// ReadManifest rejects manifests with files outside of the backup, such as absolute paths or paths with ..
+		b, err := os.ReadFile(filepath.Join(projectDir, l.File))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to read backup of language '%s'", code)
+		}
+
+		sum := md5.Sum(b)
+		if hex.EncodeToString(sum[:]) != l.Checksum {
+			return nil, errors.Errorf("Checksum of backup of language '%s' does not match the manifest", code)
+		}
+
+		files[code] = b
+	}
+
+	results := []RestoreResult{}
+
+	for _, code := range languages {
+		res, err := client.UploadProject(ctx, poedit.UploadProjectRequest{
+			ID:        projectID,
+			Updating:  poedit.UpdatingTermsTranslations,
+			File:      bytes.NewReader(files[code]),
+			FileName:  manifest.Languages[code].File,
+			Language:  code,
+			Overwrite: true,
+			SyncTerms: opts.Sync,
+		})
+		if err != nil {
+			return results, errors.Wrapf(err, "Failed to restore language '%s'", code)
+		}
+
+		results = append(results, RestoreResult{
+			LanguageCode:        code,
+			TermsAdded:          res.Result.Terms.Added,
+			TermsDeleted:        res.Result.Terms.Deleted,
+			TranslationsAdded:   res.Result.Translations.Added,
+			TranslationsUpdated: res.Result.Translations.Updated,
+		})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	archiveChecksumRecord = "PARROT.checksum"
+	archiveUpdatedRecord  = "PARROT.updated"
+)
+
+// RestoreOptions controls how an archive is restored into a cache.
+type RestoreOptions struct {
+	// Renew restores translations as if they were cached now, instead of keeping their age.
+	Renew bool
+}
+
+// RestoreResult counts the translations of a restored archive.
+type RestoreResult struct {
+	Restored int
+	// Expired are the translations which were too old for the ttl of the cache, and were skipped.
+	Expired int
+}
+
+// Dump writes every cached translation to a gzip compressed tar archive, which can be restored into any cache by Restore.
+// Every translation is a file named by its project, language and format, such as 123/da/key_value_json,
+// with its creation time as modification time, and its checksum and update time as pax records.
+// Translations which expire while dumping are skipped. Dump returns the number of translations written.
+func Dump(ctx context.Context, c Cache, w io.Writer) (int, error) {
+	entries, err := c.ListTranslations(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "Failed to list cached translations")
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dumped := 0
+
+	for _, e := range entries {
+		item, err := c.GetTranslation(ctx, e.ProjectID, e.LanguageCode, e.Format)
+		if errors.Is(err, ErrCacheMiss) {
+			continue
+		}
+
+		if err != nil {
+			return dumped, err
+		}
+
+		records := map[string]string{archiveChecksumRecord: item.Checksum}
+		if !item.Updated.IsZero() {
+			records[archiveUpdatedRecord] = item.Updated.Format(time.RFC3339Nano)
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag:   tar.TypeReg,
+			Name:       fmt.Sprintf("%d/%s/%s", e.ProjectID, e.LanguageCode, e.Format),
+			Mode:       0o644,
+			Size:       int64(len(item.Data)),
+			ModTime:    item.CreatedAt,
+			PAXRecords: records,
+			Format:     tar.FormatPAX,
+		}); err != nil {
+			return dumped, errors.Wrap(err, "Failed to write cache archive")
+		}
+
+		if _, err := tw.Write(item.Data); err != nil {
+			return dumped, errors.Wrap(err, "Failed to write cache archive")
+		}
+
+		dumped++
+	}
+
+	if err := tw.Close(); err != nil {
+		return dumped, errors.Wrap(err, "Failed to write cache archive")
+	}
+
+	if err := gz.Close(); err != nil {
+		return dumped, errors.Wrap(err, "Failed to write cache archive")
+	}
+
+	return dumped, nil
+}
+
+// Restore loads the translations of an archive written by Dump into a cache.
+// The checksums of the translations are verified, and translations older than the ttl of the cache are skipped unless renewed.
+func Restore(ctx context.Context, c Cache, r io.Reader, opts RestoreOptions) (RestoreResult, error) {
+	var result RestoreResult
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return result, errors.Wrap(err, "Failed to read cache archive")
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+
+		if err != nil {
+			return result, errors.Wrap(err, "Failed to read cache archive")
+		}
+
+		parts := strings.Split(header.Name, "/")
+		if len(parts) != 3 {
+			return result, errors.Errorf("Unexpected file '%s' in cache archive", header.Name)
+		}
+
+		projectID, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return result, errors.Errorf("Unexpected file '%s' in cache archive", header.Name)
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return result, errors.Wrapf(err, "Failed to read '%s' from cache archive", header.Name)
+		}
+
+		item := &CacheItem{
+			CreatedAt: header.ModTime,
+			Checksum:  header.PAXRecords[archiveChecksumRecord],
+			Data:      data,
+		}
+
+		sum := md5.Sum(data)
+		if hex.EncodeToString(sum[:]) != item.Checksum {
+			return result, errors.Errorf("Checksum of '%s' in cache archive does not match", header.Name)
+		}
+
+		if updated, ok := header.PAXRecords[archiveUpdatedRecord]; ok {
+			if item.Updated, err = time.Parse(time.RFC3339Nano, updated); err != nil {
+				return result, errors.Errorf("Invalid update time of '%s' in cache archive", header.Name)
+			}
+		}
+
+		if opts.Renew {
+			item.CreatedAt = time.Now()
+		}
+
+		// The cache decides whether the item is expired, as the ttl may be about to be passed
+		err = c.RestoreTranslation(ctx, projectID, parts[1], parts[2], item)
+		if errors.Is(err, ErrExpired) {
+			result.Expired++
+
+			continue
+		}
+
+		if err != nil {
+			return result, err
+		}
+
+		result.Restored++
+	}
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestCache(t *testing.T, ttl time.Duration) *FilesystemCache {
+	t.Helper()
+
+	c, err := NewFilesystemCache(t.TempDir(), ttl)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	return c
+}
+
+func TestDumpRestore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	source := newTestCache(t, time.Hour*24)
+
+	cached := []struct {
+		projectID    int
+		languageCode string
+		data         string
+		createdAt    time.Time
+	}{
+		{projectID: 1, languageCode: "da", data: `{"hello":"Hej"}`, createdAt: now.Add(-time.Minute)},
+		{projectID: 1, languageCode: "de", data: `{"hello":"Hallo"}`, createdAt: now.Add(-time.Hour * 2)},
+	}
+
+	for _, c := range cached {
+		if err := source.RestoreTranslation(ctx, c.projectID, c.languageCode, "key_value_json", &CacheItem{
+			CreatedAt: c.createdAt,
+			Updated:   now.Add(-time.Hour * 3),
+			Data:      []byte(c.data),
+		}); err != nil {
+			t.Fatalf("Failed to cache translation: %v", err)
+		}
+	}
+
+	var archive bytes.Buffer
+
+	dumped, err := Dump(ctx, source, &archive)
+	if err != nil {
+		t.Fatalf("Dump() error = %v", err)
+	}
+
+	if dumped != len(cached) {
+		t.Fatalf("Dump() = %d, want %d", dumped, len(cached))
+	}
+
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		renew    bool
+		restored int
+		expired  int
+	}{
+		{name: "all within ttl", ttl: time.Hour * 24, restored: 2},
+		{name: "older than ttl", ttl: time.Hour, restored: 1, expired: 1},
+		{name: "renewed", ttl: time.Hour, renew: true, restored: 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			target := newTestCache(t, tt.ttl)
+
+			result, err := Restore(ctx, target, bytes.NewReader(archive.Bytes()), RestoreOptions{Renew: tt.renew})
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+
+			if result.Restored != tt.restored || result.Expired != tt.expired {
+				t.Fatalf("Restore() = %+v, want %d restored and %d expired", result, tt.restored, tt.expired)
+			}
+
+			for _, c := range cached {
+				item, err := target.GetTranslation(ctx, c.projectID, c.languageCode, "key_value_json")
+				if err == ErrCacheMiss && !tt.renew && now.Sub(c.createdAt) > tt.ttl {
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("GetTranslation(%s) error = %v", c.languageCode, err)
+				}
+
+				want, _ := source.GetTranslation(ctx, c.projectID, c.languageCode, "key_value_json")
+
+				if string(item.Data) != c.data || item.Checksum != want.Checksum || !item.Updated.Equal(want.Updated) {
+					t.Errorf("GetTranslation(%s) = %+v, want %+v", c.languageCode, item, want)
+				}
+
+				if !tt.renew && !item.CreatedAt.Equal(c.createdAt) {
+					t.Errorf("GetTranslation(%s) created at %v, want %v", c.languageCode, item.CreatedAt, c.createdAt)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		checksum string
+	}{
+		{name: "checksum mismatch", file: "1/da/key_value_json", checksum: "0123456789abcdef0123456789abcdef"},
+		{name: "unexpected file", file: "1/da", checksum: "99914b932bd37a50b983c5e7c90ae93b"},
+		{name: "invalid project", file: "web/da/key_value_json", checksum: "99914b932bd37a50b983c5e7c90ae93b"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var archive bytes.Buffer
+
+			gz := gzip.NewWriter(&archive)
+			tw := tar.NewWriter(gz)
+
+			data := []byte(`{}`)
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag:   tar.TypeReg,
+				Name:       tt.file,
+				Mode:       0o644,
+				Size:       int64(len(data)),
+				ModTime:    time.Now(),
+				PAXRecords: map[string]string{archiveChecksumRecord: tt.checksum},
+				Format:     tar.FormatPAX,
+			}); err != nil {
+				t.Fatalf("Failed to write archive: %v", err)
+			}
+
+			if _, err := tw.Write(data); err != nil {
+				t.Fatalf("Failed to write archive: %v", err)
+			}
+
+			tw.Close()
+			gz.Close()
+
+			if _, err := Restore(context.Background(), newTestCache(t, time.Hour), &archive, RestoreOptions{}); err == nil {
+				t.Error("Restore() expected an error")
+			}
+		})
+	}
+}
@@ -3,22 +3,65 @@ package cache
 import (
 	"context"
 	"errors"
+	"io"
+	"strconv"
+	"strings"
 	"time"
 )
 
 var ErrCacheMiss = errors.New("Cache miss")
 
+// ErrExpired is returned when restoring an item which is older than the ttl of the cache.
+var ErrExpired = errors.New("Cache item expired")
+
 type CacheItem struct {
 	CreatedAt time.Time
-	Checksum  string
-	Data      []byte
+	// Updated is the time poeditor reported the language as last changed when the item was fetched.
+	Updated  time.Time
+	Checksum string
+	Data     []byte
+}
+
+// CacheEntry describes a cached translation, without its data.
+type CacheEntry struct {
+	ProjectID    int
+	LanguageCode string
+	Format       string
+	CreatedAt    time.Time
+	Updated      time.Time
+	Checksum     string
+	Size         int64
 }
 
 type Cache interface {
 	GetTranslation(ctx context.Context, projectID int, languageCode, format string) (item *CacheItem, err error)
-	SetTranslation(ctx context.Context, projectID int, languageCode, format string, data []byte) (checksum string, err error)
+	SetTranslation(ctx context.Context, projectID int, languageCode, format string, updated time.Time, data io.Reader) (checksum string, err error)
+	// RestoreTranslation stores an item cached elsewhere as is, keeping its creation time such that it expires as it would have there.
+	// It returns ErrExpired, and stores nothing, if the item is already expired.
+	RestoreTranslation(ctx context.Context, projectID int, languageCode, format string, item *CacheItem) (err error)
+	RenewTranslation(ctx context.Context, projectID int, languageCode, format string) (err error)
 	PurgeTranslation(ctx context.Context, projectID int, languageCode string) (err error)
 	PurgeProject(ctx context.Context, projectID int) (err error)
+	// ListTranslations returns every cached translation, including expired ones not yet removed.
+	ListTranslations(ctx context.Context) (entries []CacheEntry, err error)
 	GetTTL() time.Duration
+	// SetTTL changes the ttl of the cache, which applies to translations already cached as well.
+	SetTTL(ttl time.Duration)
 	PingContext(ctx context.Context) error
 }
+
+// parseKey parses the project id, language code and format of a cache key, made of the three joined by sep.
+// Language codes never contain the separator, while formats such as key_value_json may.
+func parseKey(key, sep string) (projectID int, languageCode, format string, ok bool) {
+	parts := strings.SplitN(key, sep, 3)
+	if len(parts) != 3 || parts[1] == "" || parts[2] == "" {
+		return 0, "", "", false
+	}
+
+	projectID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", "", false
+	}
+
+	return projectID, parts[1], parts[2], true
+}
@@ -1,15 +1,18 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -17,7 +20,8 @@ import (
 
 type FilesystemCache struct {
 	dir string
-	ttl time.Duration
+	// ttl is accessed atomically, as it may be changed while serving
+	ttl int64
 }
 
 func NewFilesystemCache(cacheDir string, ttl time.Duration) (*FilesystemCache, error) {
@@ -28,7 +32,7 @@ func NewFilesystemCache(cacheDir string, ttl time.Duration) (*FilesystemCache, e
 
 	return &FilesystemCache{
 		dir: cacheDir,
-		ttl: ttl,
+		ttl: int64(ttl),
 	}, nil
 }
 
@@ -43,7 +47,7 @@ func (f *FilesystemCache) GetTranslation(ctx context.Context, projectID int, lan
 		return nil, errors.Wrap(err, "Failed to get cached file state from OS")
 	}
 
-	if time.Since(info.ModTime()) > f.ttl {
+	if time.Since(info.ModTime()) > f.GetTTL() {
 		return nil, ErrCacheMiss
 	}
 
@@ -55,43 +59,127 @@ func (f *FilesystemCache) GetTranslation(ctx context.Context, projectID int, lan
 		return nil, err
 	}
 
-	md5, err := ioutil.ReadFile(fmt.Sprintf("%s.md5", filePath))
+	md5sum, err := ioutil.ReadFile(fmt.Sprintf("%s.md5", filePath))
 	if err != nil {
 		return nil, ErrCacheMiss
 	}
 
+	// The checksum is moved into place before the data, so a mismatch means the translation is being replaced
+	if sum := md5.Sum(b); hex.EncodeToString(sum[:]) != string(md5sum) {
+		return nil, ErrCacheMiss
+	}
+
+	var updated time.Time
+	if u, err := ioutil.ReadFile(f.updatedPath(projectID, languageCode, format)); err == nil {
+		updated, _ = time.Parse(time.RFC3339, string(u))
+	}
+
 	return &CacheItem{
-		Checksum:  string(md5),
+		Checksum:  string(md5sum),
 		Data:      b,
 		CreatedAt: info.ModTime(),
+		Updated:   updated,
 	}, nil
 }
 
-func (f *FilesystemCache) SetTranslation(ctx context.Context, projectID int, languageCode, format string, data []byte) (string, error) {
-	if err := ioutil.WriteFile(
-		f.filePath(projectID, languageCode, format),
-		data,
-		os.ModePerm,
-	); err != nil {
-		return "", err
+func (f *FilesystemCache) SetTranslation(ctx context.Context, projectID int, languageCode, format string, updated time.Time, r io.Reader) (string, error) {
+	// Stream into a temporary file, such that readers never see a partially written translation
+	tmp, err := os.CreateTemp(f.dir, ".parrot-*")
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create temporary cache file")
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := md5.New()
+
+	_, err = io.Copy(io.MultiWriter(tmp, hasher), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
 	}
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to write cache file")
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", errors.Wrap(err, "Failed to set cache file permissions")
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
 
-	hashBytes := md5.Sum(data)
-	hash := hex.EncodeToString(hashBytes[:])
+	// The metadata is moved into place before the data, such that the new data is never read with the old checksum.
+	// Until the data follows, reads of the translation miss, as the checksum does not match the old data
+	if err := f.writeFile(f.md5Path(projectID, languageCode, format), []byte(hash)); err != nil {
+		return "", err
+	}
 
-	if err := ioutil.WriteFile(
-		f.md5Path(projectID, languageCode, format),
-		[]byte(hash),
-		os.ModePerm,
-	); err != nil {
+	if err := f.writeFile(f.updatedPath(projectID, languageCode, format), []byte(updated.Format(time.RFC3339))); err != nil {
 		return "", err
 	}
 
+	if err := os.Rename(tmp.Name(), f.filePath(projectID, languageCode, format)); err != nil {
+		return "", errors.Wrap(err, "Failed to move cache file into place")
+	}
+
 	return hash, nil
 }
 
+// writeFile writes a small file of the cache through a temporary file, such that it is replaced in a single step.
+func (f *FilesystemCache) writeFile(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(f.dir, ".parrot-*")
+	if err != nil {
+		return errors.Wrap(err, "Failed to create temporary cache file")
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to write cache file")
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return errors.Wrap(err, "Failed to set cache file permissions")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), filePath), "Failed to move cache file into place")
+}
+
+func (f *FilesystemCache) RestoreTranslation(ctx context.Context, projectID int, languageCode, format string, item *CacheItem) error {
+	if time.Since(item.CreatedAt) > f.GetTTL() {
+		return ErrExpired
+	}
+
+	if _, err := f.SetTranslation(ctx, projectID, languageCode, format, item.Updated, bytes.NewReader(item.Data)); err != nil {
+		return err
+	}
+
+	// The age of a cached file is its modification time
+	if err := os.Chtimes(f.filePath(projectID, languageCode, format), item.CreatedAt, item.CreatedAt); err != nil {
+		return errors.Wrap(err, "Failed to set age of restored cache file")
+	}
+
+	return nil
+}
+
+// RenewTranslation resets the age of a cached translation, by touching the cached file.
+func (f *FilesystemCache) RenewTranslation(ctx context.Context, projectID int, languageCode, format string) error {
+	now := time.Now()
+
+	err := os.Chtimes(f.filePath(projectID, languageCode, format), now, now)
+	if os.IsNotExist(err) {
+		return ErrCacheMiss
+	}
+	if err != nil {
+		return errors.Wrap(err, "Failed to renew cached file")
+	}
+
+	return nil
+}
+
 func (f *FilesystemCache) PurgeTranslation(ctx context.Context, projectID int, languageCode string) error {
-	prefix := fmt.Sprintf("%d_%s", projectID, languageCode)
+	prefix := fmt.Sprintf("%d_%s_", projectID, languageCode)
 
 	err := f.removeFilesWithPrefix(prefix)
 	if err != nil {
@@ -112,6 +200,55 @@ func (f *FilesystemCache) PurgeProject(ctx context.Context, projectID int) error
 	return nil
 }
 
+// ListTranslations returns every translation file in the cache directory, including expired ones.
+func (f *FilesystemCache) ListTranslations(ctx context.Context) ([]CacheEntry, error) {
+	files, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read cache directory")
+	}
+
+	entries := []CacheEntry{}
+
+	for _, file := range files {
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") || filepath.Ext(file.Name()) != "" {
+			continue
+		}
+
+		projectID, languageCode, format, ok := parseKey(file.Name(), "_")
+		if !ok {
+			continue
+		}
+
+		info, err := file.Info()
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to get state of cache file '%s'", file.Name())
+		}
+
+		entry := CacheEntry{
+			ProjectID:    projectID,
+			LanguageCode: languageCode,
+			Format:       format,
+			CreatedAt:    info.ModTime(),
+			Size:         info.Size(),
+		}
+
+		if md5, err := ioutil.ReadFile(f.md5Path(projectID, languageCode, format)); err == nil {
+			entry.Checksum = string(md5)
+		}
+
+		if u, err := ioutil.ReadFile(f.updatedPath(projectID, languageCode, format)); err == nil {
+			entry.Updated, _ = time.Parse(time.RFC3339, string(u))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
 func (f *FilesystemCache) filePath(projectID int, languageCode, format string) string {
 	return path.Join(
 		f.dir,
@@ -129,6 +266,16 @@ func (f *FilesystemCache) md5Path(projectID int, languageCode, format string) st
 	)
 }
 
+func (f *FilesystemCache) updatedPath(projectID int, languageCode, format string) string {
+	return path.Join(
+		f.dir,
+		fmt.Sprintf(
+			"%s.updated",
+			f.filename(projectID, languageCode, format),
+		),
+	)
+}
+
 func (f *FilesystemCache) filename(projectID int, languageCode, format string) string {
 	return fmt.Sprintf("%d_%s_%s", projectID, languageCode, format)
 }
@@ -148,5 +295,9 @@ func (f *FilesystemCache) removeFilesWithPrefix(prefix string) error {
 }
 
 func (f *FilesystemCache) GetTTL() time.Duration {
-	return f.ttl
+	return time.Duration(atomic.LoadInt64(&f.ttl))
+}
+
+func (f *FilesystemCache) SetTTL(ttl time.Duration) {
+	atomic.StoreInt64(&f.ttl, int64(ttl))
 }
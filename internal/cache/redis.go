@@ -5,10 +5,11 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	redisCache "github.com/go-redis/cache/v8"
 	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -16,18 +17,21 @@ import (
 
 const (
 	redisScanCount = 10
+	// redisKeyPrefix is the prefix of the keys of parrot, such that other keys of the database are never listed or purged.
+	redisKeyPrefix = "parrot:"
+
+	redisFieldCreatedAt = "createdAt"
+	redisFieldUpdated   = "updated"
+	redisFieldChecksum  = "checksum"
+	redisFieldData      = "data"
 )
 
+// RedisCache caches every translation as a redis hash of its metadata and data,
+// such that the metadata can be read without the data. The keys of the hashes are prefixed by redisKeyPrefix.
 type RedisCache struct {
-	c   *redis.Client
-	rc  *redisCache.Cache
-	ttl time.Duration
-}
-
-type RedisCacheItem struct {
-	CreatedAt time.Time
-	Checksum  string
-	Data      []byte
+	c *redis.Client
+	// ttl is accessed atomically, as it may be changed while serving
+	ttl int64
 }
 
 type RedisLogger struct {
@@ -40,50 +44,52 @@ func (r *RedisLogger) Printf(ctx context.Context, format string, v ...interface{
 
 func NewRedisCache(c *redis.Client, ttl time.Duration) *RedisCache {
 	return &RedisCache{
-		c: c,
-		rc: redisCache.New(&redisCache.Options{
-			Redis: c,
-		}),
-		ttl: ttl,
+		c:   c,
+		ttl: int64(ttl),
 	}
 }
 
 func (r *RedisCache) GetTranslation(ctx context.Context, projectID int, languageCode, format string) (*CacheItem, error) {
 	key := r.key(projectID, languageCode, format)
 
-	var item RedisCacheItem
-	err := r.rc.Get(ctx, key, &item)
+	fields, err := r.c.HGetAll(ctx, key).Result()
 	if err != nil {
-		if strings.Contains(err.Error(), "key is missing") {
-			return nil, ErrCacheMiss
-		}
-
 		return nil, errors.Wrapf(err, "Could not get cache data for key %s", key)
 	}
 
-	return &CacheItem{
-		CreatedAt: item.CreatedAt,
-		Checksum:  item.Checksum,
-		Data:      item.Data,
-	}, nil
+	if len(fields) == 0 {
+		return nil, ErrCacheMiss
+	}
+
+	item := &CacheItem{
+		Checksum: fields[redisFieldChecksum],
+		Data:     []byte(fields[redisFieldData]),
+	}
+
+	if item.CreatedAt, item.Updated, err = parseRedisTimes(fields[redisFieldCreatedAt], fields[redisFieldUpdated]); err != nil {
+		return nil, errors.Wrapf(err, "Invalid cache data for key %s", key)
+	}
+
+	return item, nil
 }
 
-func (r *RedisCache) SetTranslation(ctx context.Context, projectID int, languageCode, format string, data []byte) (string, error) {
+func (r *RedisCache) SetTranslation(ctx context.Context, projectID int, languageCode, format string, updated time.Time, reader io.Reader) (string, error) {
 	key := r.key(projectID, languageCode, format)
 
+	// A translation is stored as a single redis value, so unlike the filesystem cache it can not be streamed into place
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to read cache data for key %s", key)
+	}
+
 	hashBytes := md5.Sum(data)
 	checksum := hex.EncodeToString(hashBytes[:])
 
-	if err := r.rc.Set(&redisCache.Item{
-		Ctx: ctx,
-		Key: key,
-		TTL: r.ttl,
-		Value: RedisCacheItem{
-			CreatedAt: time.Now(),
-			Checksum:  checksum,
-			Data:      data,
-		},
-		SkipLocalCache: true,
+	if err := r.set(ctx, key, r.GetTTL(), &CacheItem{
+		CreatedAt: time.Now(),
+		Updated:   updated,
+		Checksum:  checksum,
+		Data:      data,
 	}); err != nil {
 		return "", errors.Wrapf(err, "Error while setting cache data for key %s", key)
 	}
@@ -91,8 +97,75 @@ func (r *RedisCache) SetTranslation(ctx context.Context, projectID int, language
 	return checksum, nil
 }
 
+func (r *RedisCache) RestoreTranslation(ctx context.Context, projectID int, languageCode, format string, item *CacheItem) error {
+	key := r.key(projectID, languageCode, format)
+
+	// Expire the item when it would have expired in the cache it was restored from
+	ttl := r.GetTTL() - time.Since(item.CreatedAt)
+	if ttl <= 0 {
+		return ErrExpired
+	}
+
+	if err := r.set(ctx, key, ttl, item); err != nil {
+		return errors.Wrapf(err, "Error while restoring cache data for key %s", key)
+	}
+
+	return nil
+}
+
+// set replaces the hash of a translation and its expiry in a single transaction, such that it is never read half written.
+func (r *RedisCache) set(ctx context.Context, key string, ttl time.Duration, item *CacheItem) error {
+	updated := ""
+	if !item.Updated.IsZero() {
+		updated = item.Updated.Format(time.RFC3339Nano)
+	}
+
+	_, err := r.c.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			redisFieldCreatedAt, item.CreatedAt.Format(time.RFC3339Nano),
+			redisFieldUpdated, updated,
+			redisFieldChecksum, item.Checksum,
+			redisFieldData, item.Data,
+		)
+		pipe.PExpire(ctx, key, ttl)
+
+		return nil
+	})
+
+	return err
+}
+
+// renewScript resets the creation time and expiry of a cached translation in a single step, if it is still cached,
+// such that a translation set while renewing is never replaced by the one renewed.
+var renewScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+redis.call("PEXPIRE", KEYS[1], ARGV[3])
+return 1
+`)
+
+// RenewTranslation resets the age and expiry of a cached translation, without changing its content.
+func (r *RedisCache) RenewTranslation(ctx context.Context, projectID int, languageCode, format string) error {
+	key := r.key(projectID, languageCode, format)
+
+	renewed, err := renewScript.Run(ctx, r.c, []string{key},
+		redisFieldCreatedAt, time.Now().Format(time.RFC3339Nano), r.GetTTL().Milliseconds(),
+	).Int()
+	if err != nil {
+		return errors.Wrapf(err, "Error while renewing cache data for key %s", key)
+	}
+
+	if renewed == 0 {
+		return ErrCacheMiss
+	}
+
+	return nil
+}
+
 func (r *RedisCache) PurgeTranslation(ctx context.Context, projectID int, languageCode string) error {
-	pattern := fmt.Sprintf("%d:%s:*", projectID, languageCode)
+	pattern := fmt.Sprintf("%s%d:%s:*", redisKeyPrefix, projectID, languageCode)
 
 	if err := r.deleteKeysMatching(ctx, pattern); err != nil {
 		return errors.Wrapf(err, "Failed to remove cached language '%s' for project '%d'", languageCode, projectID)
@@ -102,7 +175,7 @@ func (r *RedisCache) PurgeTranslation(ctx context.Context, projectID int, langua
 }
 
 func (r *RedisCache) PurgeProject(ctx context.Context, projectID int) error {
-	pattern := fmt.Sprintf("%d:*", projectID)
+	pattern := fmt.Sprintf("%s%d:*", redisKeyPrefix, projectID)
 
 	if err := r.deleteKeysMatching(ctx, pattern); err != nil {
 		return errors.Wrapf(err, "Failed to remove cached project '%d'", projectID)
@@ -111,8 +184,92 @@ func (r *RedisCache) PurgeProject(ctx context.Context, projectID int) error {
 	return nil
 }
 
+// ListTranslations returns every translation in redis, reading only their metadata.
+// Expired translations are removed by redis, and never listed.
+func (r *RedisCache) ListTranslations(ctx context.Context) ([]CacheEntry, error) {
+	keys, err := r.getKeysMatching(ctx, redisKeyPrefix+"*")
+	if err != nil {
+		return nil, err
+	}
+
+	metas := make([]*redis.SliceCmd, len(keys))
+	sizes := make([]*redis.Cmd, len(keys))
+
+	if _, err := r.c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			metas[i] = pipe.HMGet(ctx, key, redisFieldCreatedAt, redisFieldUpdated, redisFieldChecksum)
+			// go-redis has no command of its own for HSTRLEN
+			sizes[i] = pipe.Do(ctx, "HSTRLEN", key, redisFieldData)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, errors.Wrap(err, "Could not get cache metadata")
+	}
+
+	entries := []CacheEntry{}
+
+	for i, key := range keys {
+		projectID, languageCode, format, ok := parseKey(strings.TrimPrefix(key, redisKeyPrefix), ":")
+		if !ok {
+			continue
+		}
+
+		meta := metas[i].Val()
+
+		// The translation expired since it was listed
+		if meta[0] == nil {
+			continue
+		}
+
+		size, _ := sizes[i].Int64()
+
+		createdAt, updated, err := parseRedisTimes(toString(meta[0]), toString(meta[1]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid cache data for key %s", key)
+		}
+
+		entries = append(entries, CacheEntry{
+			ProjectID:    projectID,
+			LanguageCode: languageCode,
+			Format:       format,
+			CreatedAt:    createdAt,
+			Updated:      updated,
+			Checksum:     toString(meta[2]),
+			Size:         size,
+		})
+	}
+
+	return entries, nil
+}
+
 func (r *RedisCache) key(projectID int, languageCode, format string) string {
-	return fmt.Sprintf("%d:%s:%s", projectID, languageCode, format)
+	return fmt.Sprintf("%s%d:%s:%s", redisKeyPrefix, projectID, languageCode, format)
+}
+
+// parseRedisTimes parses the creation and update times of a cached translation. The update time is empty if unknown.
+func parseRedisTimes(createdAt, updated string) (time.Time, time.Time, error) {
+	created, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Wrap(err, "Invalid creation time")
+	}
+
+	if updated == "" {
+		return created, time.Time{}, nil
+	}
+
+	u, err := time.Parse(time.RFC3339Nano, updated)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.Wrap(err, "Invalid update time")
+	}
+
+	return created, u, nil
+}
+
+func toString(v interface{}) string {
+	s, _ := v.(string)
+
+	return s
 }
 
 func (r *RedisCache) deleteKeysMatching(ctx context.Context, pattern string) error {
@@ -121,6 +278,10 @@ func (r *RedisCache) deleteKeysMatching(ctx context.Context, pattern string) err
 		return err
 	}
 
+	if len(keys) == 0 {
+		return nil
+	}
+
 	if err := r.c.Del(ctx, keys...).Err(); err != nil {
 		return errors.Wrapf(err, "Failed to remove redis keys matching '%s'", pattern)
 	}
@@ -146,18 +307,22 @@ func (r *RedisCache) getKeysMatching(ctx context.Context, pattern string) ([]str
 			return nil, errors.Wrapf(err, "Failed to retrieve keys from redis matching pattern '%s'", pattern)
 		}
 
+		allKeys = append(allKeys, keys...)
+
 		if cursor == 0 {
 			break
 		}
-
-		allKeys = append(allKeys, keys...)
 	}
 
 	return allKeys, nil
 }
 
 func (r *RedisCache) GetTTL() time.Duration {
-	return r.ttl
+	return time.Duration(atomic.LoadInt64(&r.ttl))
+}
+
+func (r *RedisCache) SetTTL(ttl time.Duration) {
+	atomic.StoreInt64(&r.ttl, int64(ttl))
 }
 
 func (r *RedisCache) PingContext(ctx context.Context) error {
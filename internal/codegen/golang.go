@@ -0,0 +1,345 @@
+// Package codegen generates code for the terms of a project, so that uses of terms are checked at compile time.
+package codegen
+
+import (
+	"bytes"
+	"go/format"
+	"go/token"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/internal/terms"
+)
+
+// GoOptions controls the generated go code.
+type GoOptions struct {
+	Package   string
+	ProjectID int
+	// Accessors generates a function for every term, returning its translation with the placeholders filled in.
+	Accessors bool
+}
+
+type goTerm struct {
+	Key         string
+	Name        string
+	Const       string
+	Translation string
+	// Accessor is false for terms with icu plural or select arguments, which need an icu message formatter.
+	Accessor bool
+	Params   []goParam
+	Printf   bool
+	// Args are the names of the arguments of the printf verbs, in the order of their indexes.
+	Args         []string
+	Replacements []goParam
+}
+
+type goParam struct {
+	Name string
+	Type string
+	// Text is the placeholder replaced by the parameter, and empty for arguments of printf verbs.
+	Text string
+}
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"quote":   strconv.Quote,
+	"comment": goComment,
+}).Parse(`// Code generated by parrot gen go. DO NOT EDIT.
+
+// Package {{ .Package }} contains the keys of the terms of poeditor project {{ .ProjectID }}.
+package {{ .Package }}
+
+{{ if .Accessors }}
+import (
+	"context"
+	{{- if .Fmt }}
+	"fmt"
+	{{- end }}
+	{{- if .Replace }}
+	"strings"
+	{{- end }}
+
+	"github.com/uniwise/parrot/pkg/client"
+)
+{{ end }}
+
+// Term is the key of a term of poeditor project {{ .ProjectID }}.
+type Term string
+
+// Keys of the terms.
+const (
+{{- range .Terms }}
+	// {{ .Const }} is {{ comment .Translation }}
+	{{ .Const }} Term = {{ quote .Key }}
+{{- end }}
+)
+
+// All returns the keys of every term.
+func All() []Term {
+	return []Term{
+	{{- range .Terms }}
+		{{ .Const }},
+	{{- end }}
+	}
+}
+{{ if .Accessors }}
+// Get returns the translation of the term in the language.
+func (t Term) Get(ctx context.Context, c client.Client, language string) (string, error) {
+	return c.GetTerm(ctx, language, string(t))
+}
+{{ range .Terms }}{{ if .Accessor }}
+// {{ .Name }} returns the translation of {{ quote .Key }} in the language.
+func {{ .Name }}(ctx context.Context, c client.Client, language string{{ range .Params }}, {{ .Name }} {{ .Type }}{{ end }}) (string, error) {
+	{{- if or .Printf .Replacements }}
+	s, err := c.GetTerm(ctx, language, string({{ .Const }}))
+	if err != nil {
+		return "", err
+	}
+	{{- if .Printf }}
+
+	s = fmt.Sprintf(s{{ range .Args }}, {{ . }}{{ end }})
+	{{- end }}
+	{{- if .Replacements }}
+
+	s = strings.NewReplacer(
+	{{- range .Replacements }}
+		{{ quote .Text }}, {{ if eq .Type "string" }}{{ .Name }}{{ else }}fmt.Sprint({{ .Name }}){{ end }},
+	{{- end }}
+	).Replace(s)
+	{{- end }}
+
+	return s, nil
+	{{- else }}
+	return c.GetTerm(ctx, language, string({{ .Const }}))
+	{{- end }}
+}
+{{ end }}{{ end }}
+{{- end }}
+`))
+
+// GenerateGo writes go code with a constant for the key of every term, and optionally a typed accessor function for every term,
+// using the client of pkg/client. The placeholders of the translation of a term become the typed parameters of its accessor.
+// Terms are identified by their key alone, like the json format used by the client, so terms differing only by context are generated once.
+// Terms with icu plural or select arguments get no accessor, as their translations must be formatted by an icu message formatter.
+// The names of a term only depend on the term itself, so generation fails when two terms have the same name,
+// rather than letting one term rename another.
+func GenerateGo(w io.Writer, opts GoOptions, entries []terms.Entry) error {
+	if !token.IsIdentifier(opts.Package) {
+		return errors.Errorf("Invalid package name '%s'", opts.Package)
+	}
+
+	data := struct {
+		GoOptions
+		Terms   []goTerm
+		Fmt     bool
+		Replace bool
+	}{GoOptions: opts}
+
+	seen := map[string]bool{}
+	// names maps the names of the package to the term they were generated for, and to nothing if reserved
+	names := map[string]string{"All": "", "Term": ""}
+
+	for _, e := range entries {
+		if seen[e.Term.Term] {
+			continue
+		}
+		seen[e.Term.Term] = true
+
+		t := goTerm{
+			Key:         e.Term.Term,
+			Translation: translationOf(e),
+		}
+
+		t.Name = exportedName(e.Term.Term)
+		t.Const = "Key" + t.Name
+
+		if err := claimName(t.Const, t.Key, names); err != nil {
+			return err
+		}
+
+		if HasComplexArgument(t.Translation) {
+			data.Terms = append(data.Terms, t)
+
+			continue
+		}
+
+		t.Accessor = true
+
+		if opts.Accessors {
+			if err := claimName(t.Name, t.Key, names); err != nil {
+				return err
+			}
+		}
+
+		// The parameters of the accessor must not shadow its other parameters, its variables or the imported packages
+		params := map[string]bool{
+			"ctx": true, "c": true, "language": true, "s": true, "err": true,
+			"context": true, "client": true, "fmt": true, "strings": true,
+		}
+		// named maps the names of named placeholders to their parameter, as a name can be written in several ways
+		named := map[string]goParam{}
+		// args maps the indexes of printf arguments to their position in the parameters, as an argument can be used by several verbs
+		args := map[int]int{}
+		maxArg := 0
+
+		for _, p := range Placeholders(t.Translation) {
+			if p.Name == "" {
+				t.Printf = true
+
+				if i, ok := args[p.Index]; ok {
+					// An argument formatted by verbs of different types can only be given as any value
+					if t.Params[i].Type != p.Type {
+						t.Params[i].Type = "interface{}"
+					}
+
+					continue
+				}
+
+				args[p.Index] = len(t.Params)
+				t.Params = append(t.Params, goParam{Name: uniqueName("arg"+strconv.Itoa(p.Index), params), Type: p.Type})
+
+				if p.Index > maxArg {
+					maxArg = p.Index
+				}
+
+				continue
+			}
+
+			param, ok := named[p.Name]
+			if !ok {
+				param = goParam{Name: uniqueName(unexportedName(p.Name), params), Type: p.Type, Text: p.Text}
+				named[p.Name] = param
+				t.Params = append(t.Params, param)
+			}
+
+			param.Text = p.Text
+			t.Replacements = append(t.Replacements, param)
+		}
+
+		// fmt requires every argument up to the highest index, including those no verb of the reference translation uses
+		for i := 1; i <= maxArg; i++ {
+			if _, ok := args[i]; !ok {
+				args[i] = len(t.Params)
+				t.Params = append(t.Params, goParam{Name: uniqueName("arg"+strconv.Itoa(i), params), Type: "interface{}"})
+			}
+
+			t.Args = append(t.Args, t.Params[args[i]].Name)
+		}
+
+		data.Fmt = data.Fmt || t.Printf
+		for _, p := range t.Replacements {
+			data.Replace = true
+			data.Fmt = data.Fmt || p.Type != "string"
+		}
+
+		data.Terms = append(data.Terms, t)
+	}
+
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, data); err != nil {
+		return errors.Wrap(err, "Failed to generate go code")
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "Failed to format generated go code")
+	}
+
+	_, err = w.Write(src)
+
+	return errors.Wrap(err, "Failed to write generated go code")
+}
+
+// translationOf returns the translation of an entry, using the other form of plural translations.
+func translationOf(e terms.Entry) string {
+	if len(e.Content.Plural) > 0 {
+		return e.Content.Plural["other"]
+	}
+
+	return e.Content.Singular
+}
+
+func goComment(s string) string {
+	if s == "" {
+		return "an untranslated term."
+	}
+
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) > 80 {
+		r = append(r[:77], []rune("...")...)
+	}
+
+	s = string(r)
+
+	return strconv.Quote(s)
+}
+
+// exportedName returns an exported go identifier for a term, by joining the words of the term in camel case.
+func exportedName(s string) string {
+	var b strings.Builder
+
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "T" + name
+	}
+
+	return name
+}
+
+func unexportedName(s string) string {
+	name := []rune(exportedName(s))
+	name[0] = unicode.ToLower(name[0])
+
+	if token.IsKeyword(string(name)) {
+		return string(name) + "Value"
+	}
+
+	return string(name)
+}
+
+// claimName marks a name of the package as taken by a term, failing if it is reserved or taken by another term.
+func claimName(name, term string, names map[string]string) error {
+	other, ok := names[name]
+	if !ok {
+		names[name] = term
+
+		return nil
+	}
+
+	if other == "" {
+		return errors.Errorf("The name %s of term '%s' is reserved, rename the term", name, term)
+	}
+
+	return errors.Errorf("The terms '%s' and '%s' both have the name %s, rename one of them", other, term, name)
+}
+
+// uniqueName returns the name, suffixed by a number if it is taken, and marks it as taken.
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+
+	taken[unique] = true
+
+	return unique
+}
@@ -0,0 +1,159 @@
+package codegen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/uniwise/parrot/internal/terms"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+func entry(term, translation string) terms.Entry {
+	return terms.Entry{
+		Term:    poedit.Term{Term: term},
+		Content: poedit.TranslationContent{Singular: translation},
+	}
+}
+
+func generate(t *testing.T, accessors bool, entries ...terms.Entry) (string, error) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	err := GenerateGo(&buf, GoOptions{Package: "keys", ProjectID: 1, Accessors: accessors}, entries)
+
+	return buf.String(), err
+}
+
+func TestGenerateGoNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		accessors bool
+		entries   []terms.Entry
+		expected  []string
+		err       string
+	}{
+		{
+			name:      "names only depend on the term",
+			accessors: true,
+			entries:   []terms.Entry{entry("foo", "Foo"), entry("bar", "Bar")},
+			expected:  []string{"KeyFoo Term = \"foo\"", "func Foo(", "KeyBar Term = \"bar\"", "func Bar("},
+		},
+		{
+			name:      "accessor colliding with a constant",
+			accessors: true,
+			entries:   []terms.Entry{entry("foo", "Foo"), entry("key.foo", "Key foo")},
+			err:       "'foo' and 'key.foo' both have the name KeyFoo",
+		},
+		{
+			name:      "accessor names are free without accessors",
+			accessors: false,
+			entries:   []terms.Entry{entry("foo", "Foo"), entry("key.foo", "Key foo")},
+			expected:  []string{"KeyFoo Term = \"foo\"", "KeyKeyFoo Term = \"key.foo\""},
+		},
+		{
+			name:      "terms with the same name",
+			accessors: false,
+			entries:   []terms.Entry{entry("foo bar", "Foo"), entry("foo.bar", "Foo")},
+			err:       "'foo bar' and 'foo.bar' both have the name KeyFooBar",
+		},
+		{
+			name:      "reserved name",
+			accessors: true,
+			entries:   []terms.Entry{entry("all", "All")},
+			err:       "name All of term 'all' is reserved",
+		},
+		{
+			name:      "reserved name without accessor",
+			accessors: true,
+			entries:   []terms.Entry{entry("all", "{count, plural, one {# item} other {# items}}")},
+			expected:  []string{"KeyAll Term = \"all\""},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := generate(t, tt.accessors, tt.entries...)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Expected error containing %q, got %v", tt.err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, s := range tt.expected {
+				if !strings.Contains(src, s) {
+					t.Errorf("Expected %q in generated code:\n%s", s, src)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateGoPrintf(t *testing.T) {
+	tests := []struct {
+		name        string
+		translation string
+		signature   string
+		sprintf     string
+	}{
+		{
+			name:        "verbs",
+			translation: "%s has %d items",
+			signature:   "arg1 string, arg2 int)",
+			sprintf:     "fmt.Sprintf(s, arg1, arg2)",
+		},
+		{
+			name:        "indexed verbs",
+			translation: "%[2]d items of %[1]s",
+			signature:   "arg2 int, arg1 string)",
+			sprintf:     "fmt.Sprintf(s, arg1, arg2)",
+		},
+		{
+			name:        "verbs after an indexed verb",
+			translation: "%[2]s then %s, and %[1]d",
+			signature:   "arg2 string, arg3 string, arg1 int)",
+			sprintf:     "fmt.Sprintf(s, arg1, arg2, arg3)",
+		},
+		{
+			name:        "argument used twice",
+			translation: "%s, %[1]s!",
+			signature:   "arg1 string)",
+			sprintf:     "fmt.Sprintf(s, arg1)",
+		},
+		{
+			name:        "argument of verbs of different types",
+			translation: "%[1]d or %[1]s",
+			signature:   "arg1 interface{})",
+			sprintf:     "fmt.Sprintf(s, arg1)",
+		},
+		{
+			name:        "unused argument",
+			translation: "%[2]s",
+			signature:   "arg2 string, arg1 interface{})",
+			sprintf:     "fmt.Sprintf(s, arg1, arg2)",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := generate(t, true, entry("message", tt.translation))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !strings.Contains(src, "language string, "+tt.signature) || !strings.Contains(src, tt.sprintf) {
+				t.Errorf("Expected signature %q and %q in generated code:\n%s", tt.signature, tt.sprintf, src)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package codegen
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// placeholderPattern matches the placeholders of a translation:
+// printf verbs such as %s, %d and %[1]s, and named placeholders such as {name}, {{name}}, %{name} and {count, number}.
+var placeholderPattern = regexp.MustCompile(`%%|%[-+#0]*[0-9]*(?:\.[0-9]+)?(?:\[([1-9][0-9]*)\])?[sdvfgtqx]|\{\{\s*(\w+)\s*\}\}|%\{(\w+)\}|\{(\w+)(?:\s*,\s*(\w+))?[^{}]*\}`)
+
+// complexArgumentPattern matches the start of an icu plural or select argument, such as {count, plural, one {# item} other {# items}}.
+var complexArgumentPattern = regexp.MustCompile(`\{\s*\w+\s*,\s*(?:plural|select|selectordinal)\s*,`)
+
+// HasComplexArgument reports whether a translation has an icu plural or select argument.
+// The messages nested in such arguments are chosen by an icu message formatter, so their placeholders can not be replaced as is.
+func HasComplexArgument(s string) bool {
+	return complexArgumentPattern.MatchString(s)
+}
+
+// Placeholder is a placeholder of a translation, which becomes a typed parameter of its accessor function.
+type Placeholder struct {
+	// Text is the placeholder as written in the translation.
+	Text string
+	// Name is the name of a named placeholder, and empty for printf verbs.
+	Name string
+	// Index is the one-indexed argument of a printf verb, and 0 for named placeholders.
+	// Like fmt, verbs without an explicit index such as %[2]s use the argument after the one of the previous verb.
+	Index int
+	// Type is the go type of the parameter.
+	Type string
+}
+
+// Placeholders returns the placeholders of a translation, in order of appearance.
+// Placeholders appearing more than once are only returned once.
+func Placeholders(s string) []Placeholder {
+	placeholders := []Placeholder{}
+	seen := map[string]bool{}
+	arg := 0
+
+	for _, m := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+		text := m[0]
+
+		switch {
+		case text == "%%":
+			continue
+		case strings.HasPrefix(text, "%") && !strings.HasPrefix(text, "%{"):
+			arg++
+			if m[1] != "" {
+				arg, _ = strconv.Atoi(m[1])
+			}
+
+			placeholders = append(placeholders, Placeholder{Text: text, Index: arg, Type: verbType(text[len(text)-1])})
+
+			continue
+		}
+
+		if seen[text] {
+			continue
+		}
+		seen[text] = true
+
+		placeholders = append(placeholders, Placeholder{Text: text, Name: m[2] + m[3] + m[4], Type: argumentType(m[5])})
+	}
+
+	return placeholders
+}
+
+// verbType returns the go type of the argument of a printf verb.
+func verbType(verb byte) string {
+	switch verb {
+	case 'd', 'x':
+		return "int"
+	case 'f', 'g':
+		return "float64"
+	case 't':
+		return "bool"
+	case 's', 'q':
+		return "string"
+	default:
+		return "interface{}"
+	}
+}
+
+// argumentType returns the go type of a named placeholder, from the argument type of the icu message format.
+func argumentType(icuType string) string {
+	switch icuType {
+	case "number", "plural", "selectordinal":
+		return "int"
+	default:
+		return "string"
+	}
+}
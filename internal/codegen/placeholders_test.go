@@ -0,0 +1,69 @@
+package codegen
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		translation string
+		expected    []Placeholder
+	}{
+		{
+			translation: "No placeholders, 100%%",
+			expected:    []Placeholder{},
+		},
+		{
+			translation: "%s has %5.2f%%",
+			expected: []Placeholder{
+				{Text: "%s", Index: 1, Type: "string"},
+				{Text: "%5.2f", Index: 2, Type: "float64"},
+			},
+		},
+		{
+			translation: "%[2]d of %s",
+			expected: []Placeholder{
+				{Text: "%[2]d", Index: 2, Type: "int"},
+				{Text: "%s", Index: 3, Type: "string"},
+			},
+		},
+		{
+			translation: "Hi {name}, {{name}} has {count, number} and %{other}",
+			expected: []Placeholder{
+				{Text: "{name}", Name: "name", Type: "string"},
+				{Text: "{{name}}", Name: "name", Type: "string"},
+				{Text: "{count, number}", Name: "count", Type: "int"},
+				{Text: "%{other}", Name: "other", Type: "string"},
+			},
+		},
+		{
+			translation: "{name} and {name}",
+			expected: []Placeholder{
+				{Text: "{name}", Name: "name", Type: "string"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		if placeholders := Placeholders(tt.translation); !reflect.DeepEqual(placeholders, tt.expected) {
+			t.Errorf("Expected placeholders of %q to be %+v, got %+v", tt.translation, tt.expected, placeholders)
+		}
+	}
+}
+
+func TestHasComplexArgument(t *testing.T) {
+	tests := map[string]bool{
+		"{count, plural, one {# item} other {# items}}":   true,
+		"{gender, select, male {He} other {They}}":        true,
+		"{ place , selectordinal, one {#st} other {#th}}": true,
+		"{count, number} items":                           false,
+		"{name}":                                          false,
+	}
+
+	for translation, expected := range tests {
+		if complex := HasComplexArgument(translation); complex != expected {
+			t.Errorf("Expected HasComplexArgument of %q to be %t", translation, expected)
+		}
+	}
+}
@@ -4,9 +4,18 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// UntrustedDownloadURLs counts the download urls refused for not being trusted.
+var UntrustedDownloadURLs = promauto.NewCounter(prometheus.CounterOpts{
+	Namespace: "parrot",
+	Name:      "untrusted_download_urls_total",
+	Help:      "Number of download urls refused for not being trusted",
+})
+
 func Start(path string, port int) error {
 	http.Handle(path, promhttp.Handler())
 
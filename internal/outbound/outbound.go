@@ -0,0 +1,456 @@
+package outbound
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/internal/metrics"
+)
+
+// ErrResponseTooLarge is returned when reading a response body larger than the configured max size.
+var ErrResponseTooLarge = errors.New("Response exceeds max response size")
+
+// Config is the configuration of outbound http requests, to poeditor and to its downloads.
+type Config struct {
+	// ConnectTimeout bounds establishing connections, including the tls handshake.
+	ConnectTimeout time.Duration
+	// ReadTimeout bounds waiting for response headers once a request is sent.
+	ReadTimeout time.Duration
+	// Timeout bounds entire requests, including reading the response body.
+	Timeout time.Duration
+	// Proxy is the url of a proxy to send requests through. The proxy environment variables are used when empty.
+	Proxy string
+	// CAFile is a pem file of certificate authorities to trust, on top of the system ones.
+	CAFile string
+	// MaxResponseSize is the max size in bytes of response bodies. Zero means unlimited.
+	MaxResponseSize int64
+}
+
+// NewHTTPClient creates an http client from the configuration.
+func NewHTTPClient(conf Config) (*http.Client, error) {
+	proxy := http.ProxyFromEnvironment
+	if conf.Proxy != "" {
+		u, err := url.Parse(conf.Proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid proxy url '%s'", conf.Proxy)
+		}
+
+		proxy = http.ProxyURL(u)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if conf.CAFile != "" {
+		pool, err := certPool(conf.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   conf.ConnectTimeout,
+		KeepAlive: time.Second * 30,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   conf.ConnectTimeout,
+		ResponseHeaderTimeout: conf.ReadTimeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       time.Second * 90,
+	}
+
+	return &http.Client{
+		Transport: &limitedTransport{
+			base:    transport,
+			dialer:  dialer,
+			maxSize: conf.MaxResponseSize,
+		},
+		Timeout: conf.Timeout,
+	}, nil
+}
+
+func certPool(caFile string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read ca file '%s'", caFile)
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("No certificates found in ca file '%s'", caFile)
+	}
+
+	return pool, nil
+}
+
+// limitedTransport fails reading response bodies beyond a max size.
+type limitedTransport struct {
+	base http.RoundTripper
+	// dialer is the dialer of the base transport, such that copies of the transport can dial alike
+	dialer  *net.Dialer
+	maxSize int64
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.base.RoundTrip(req)
+	if err != nil || t.maxSize <= 0 {
+		return res, err
+	}
+
+	if res.ContentLength > t.maxSize {
+		res.Body.Close()
+
+		return nil, ErrResponseTooLarge
+	}
+
+	res.Body = &limitedReadCloser{
+		ReadCloser: res.Body,
+		remaining:  t.maxSize,
+	}
+
+	return res, nil
+}
+
+type limitedReadCloser struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (r *limitedReadCloser) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	// Read one byte past the limit, to tell a body of exactly max size from a larger one
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	r.remaining -= int64(n)
+
+	if r.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+
+	return n, err
+}
+
+// ErrUntrustedURL is returned when refusing to download from an url.
+type ErrUntrustedURL struct {
+	URL    string
+	Reason string
+}
+
+func (e *ErrUntrustedURL) Error() string {
+	return fmt.Sprintf("Refused to download from untrusted url '%s': %s", e.URL, e.Reason)
+}
+
+// Downloader downloads files, such as the exports of poeditor.
+// Only urls with an allowed scheme and host are downloaded, and connections to private ip ranges are refused.
+type Downloader struct {
+	client   *http.Client
+	schemes  []string
+	hosts    []string
+	resolver *net.Resolver
+	// dialChecked is set when the addresses are checked as they are dialed, and not only when following redirects
+	dialChecked bool
+}
+
+// NewDownloader creates a downloader, allowing the given schemes and hosts.
+// A host starting with "*." allows every subdomain of the rest of the host.
+// Private addresses are only connected to if they are allowed as hosts themselves, such as "127.0.0.1".
+func NewDownloader(client *http.Client, schemes, hosts []string) *Downloader {
+	d := &Downloader{
+		schemes:  schemes,
+		hosts:    hosts,
+		resolver: net.DefaultResolver,
+	}
+
+	c := *client
+	c.CheckRedirect = d.checkRedirect
+	c.Transport, d.dialChecked = d.checkedTransport(client.Transport)
+	d.client = &c
+
+	return d
+}
+
+// checkedTransport returns a copy of the transport which checks the addresses it dials,
+// such that a host can not pass the checks with one address, and be connected to at another.
+// Transports of unknown types, and transports sending requests through a proxy, which resolves the hosts itself, are returned as is.
+func (d *Downloader) checkedTransport(rt http.RoundTripper) (http.RoundTripper, bool) {
+	switch t := rt.(type) {
+	case nil:
+		return d.checkedTransport(http.DefaultTransport)
+	case *limitedTransport:
+		base, ok := d.checkedHTTPTransport(t.base, t.dialer)
+
+		return &limitedTransport{base: base, dialer: t.dialer, maxSize: t.maxSize}, ok
+	default:
+		return d.checkedHTTPTransport(rt, nil)
+	}
+}
+
+func (d *Downloader) checkedHTTPTransport(rt http.RoundTripper, dialer *net.Dialer) (http.RoundTripper, bool) {
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return rt, false
+	}
+
+	if t.Proxy != nil {
+		if proxy, err := t.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "poeditor.com"}}); err != nil || proxy != nil {
+			return rt, false
+		}
+	}
+
+	checkedDialer := net.Dialer{
+		Timeout:   time.Second * 30,
+		KeepAlive: time.Second * 30,
+	}
+	if dialer != nil {
+		checkedDialer = *dialer
+	}
+	checkedDialer.Control = d.checkDial
+
+	checked := t.Clone()
+	checked.DialContext = checkedDialer.DialContext
+	checked.DialTLSContext = nil
+
+	return checked, true
+}
+
+// checkDial refuses connections to private addresses, unless the address is an allowed host.
+func (d *Downloader) checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !isPrivate(ip) || d.ipAllowed(ip) {
+		return nil
+	}
+
+	return d.untrusted(address, fmt.Sprintf("connecting to private address '%s' is not allowed", ip))
+}
+
+func (d *Downloader) ipAllowed(ip net.IP) bool {
+	for _, h := range d.hosts {
+		if allowed := net.ParseIP(h); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Download GETs the url and returns the response body, which must be closed by the caller.
+func (d *Downloader) Download(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, d.untrusted(rawURL, "invalid url")
+	}
+
+	if err := d.checkAllowed(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create download request")
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		var untrusted *ErrUntrustedURL
+		if errors.As(err, &untrusted) {
+			return nil, untrusted
+		}
+
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+
+		return nil, errors.Errorf("Response code '%d' from download GET", res.StatusCode)
+	}
+
+	return res.Body, nil
+}
+
+func (d *Downloader) checkAllowed(u *url.URL) error {
+	if !contains(d.schemes, u.Scheme) {
+		return d.untrusted(u.String(), fmt.Sprintf("scheme '%s' is not allowed", u.Scheme))
+	}
+
+	if !d.hostAllowed(u.Hostname()) {
+		return d.untrusted(u.String(), fmt.Sprintf("host '%s' is not allowed", u.Hostname()))
+	}
+
+	return nil
+}
+
+func (d *Downloader) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+
+	for _, h := range d.hosts {
+		h = strings.ToLower(h)
+
+		if h == host || (strings.HasPrefix(h, "*.") && strings.HasSuffix(host, h[1:])) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkRedirect follows redirects to urls with an allowed scheme.
+// When the addresses are not checked as they are dialed, redirects to hosts resolving to private ip ranges are refused here instead.
+func (d *Downloader) checkRedirect(req *http.Request, via []*http.Request) error {
+	const maxRedirects = 10
+
+	if len(via) >= maxRedirects {
+		return errors.New("Stopped after 10 redirects")
+	}
+
+	if !contains(d.schemes, req.URL.Scheme) {
+		return d.untrusted(req.URL.String(), fmt.Sprintf("redirect to scheme '%s' is not allowed", req.URL.Scheme))
+	}
+
+	if d.dialChecked {
+		return nil
+	}
+
+	ips, err := d.resolve(req.Context(), req.URL.Hostname())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to resolve redirect host '%s'", req.URL.Hostname())
+	}
+
+	for _, ip := range ips {
+		if isPrivate(ip) {
+			return d.untrusted(req.URL.String(), fmt.Sprintf("redirect to private address '%s' is not allowed", ip))
+		}
+	}
+
+	return nil
+}
+
+func (d *Downloader) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, a := range addrs {
+		ips = append(ips, a.IP)
+	}
+
+	return ips, nil
+}
+
+func (d *Downloader) untrusted(rawURL, reason string) error {
+	metrics.UntrustedDownloadURLs.Inc()
+
+	return &ErrUntrustedURL{
+		URL:    rawURL,
+		Reason: reason,
+	}
+}
+
+// nonPublicRanges are the ranges not covered by the checks of net.IP, which are not publicly routable.
+var nonPublicRanges = []*net.IPNet{
+	// "This network"
+	mustParseCIDR("0.0.0.0/8"),
+	// Carrier-grade nat
+	mustParseCIDR("100.64.0.0/10"),
+}
+
+// ipv4Translated is the range of ipv6 addresses translated from ipv4 addresses by nat64.
+var ipv4Translated = mustParseCIDR("64:ff9b::/96")
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return ipNet
+}
+
+// isPrivate reports whether an ip is in a private, loopback, link local or otherwise non public range.
+// Ipv4 addresses embedded in ipv6 addresses, such as ::ffff:10.0.0.1, are checked as the ipv4 address.
+func isPrivate(ip net.IP) bool {
+	if ip4 := embeddedIPv4(ip); ip4 != nil {
+		ip = ip4
+	}
+
+	for _, r := range nonPublicRanges {
+		if r.Contains(ip) {
+			return true
+		}
+	}
+
+	return ip.IsPrivate() ||
+		ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsUnspecified()
+}
+
+// embeddedIPv4 returns the ipv4 address of an ipv4 address, or of an ipv4 mapped (::ffff:a.b.c.d),
+// compatible (::a.b.c.d) or translated (64:ff9b::a.b.c.d) ipv6 address. Other addresses yield nil.
+func embeddedIPv4(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+
+	if len(ip) != net.IPv6len || ip.IsUnspecified() || ip.IsLoopback() {
+		return nil
+	}
+
+	if ip[:12].Equal(net.IPv6zero[:12]) || ipv4Translated.Contains(ip) {
+		return ip[12:]
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,227 @@
+package outbound
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestIsPrivate(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "8.8.8.8", want: false},
+		{ip: "2001:4860:4860::8888", want: false},
+		{ip: "10.0.0.1", want: true},
+		{ip: "172.16.0.1", want: true},
+		{ip: "192.168.1.1", want: true},
+		{ip: "127.0.0.1", want: true},
+		{ip: "169.254.169.254", want: true},
+		{ip: "0.0.0.0", want: true},
+		{ip: "0.1.2.3", want: true},
+		{ip: "100.64.0.1", want: true},
+		{ip: "::1", want: true},
+		{ip: "::", want: true},
+		{ip: "fc00::1", want: true},
+		{ip: "fe80::1", want: true},
+		{ip: "::ffff:10.0.0.1", want: true},
+		{ip: "::10.0.0.1", want: true},
+		{ip: "64:ff9b::a00:1", want: true},
+		{ip: "64:ff9b::808:808", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPrivate(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("isPrivate(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloaderCheckAllowed(t *testing.T) {
+	d := NewDownloader(http.DefaultClient, []string{"https"}, []string{"api.poeditor.com", "*.cdn.example.com"})
+
+	tests := []struct {
+		url     string
+		allowed bool
+	}{
+		{url: "https://api.poeditor.com/export.json", allowed: true},
+		{url: "https://API.POEDITOR.COM/export.json", allowed: true},
+		{url: "https://eu.cdn.example.com/export.json", allowed: true},
+		{url: "https://cdn.example.com/export.json", allowed: false},
+		{url: "https://evilcdn.example.com/export.json", allowed: false},
+		{url: "http://api.poeditor.com/export.json", allowed: false},
+		{url: "https://api.poeditor.com.evil.com/export.json", allowed: false},
+		{url: "file:///etc/passwd", allowed: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("Failed to parse url: %v", err)
+			}
+
+			err = d.checkAllowed(u)
+			if (err == nil) != tt.allowed {
+				t.Errorf("checkAllowed(%s) error = %v, want allowed %v", tt.url, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestDownloaderDownload(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/export":
+			io.WriteString(w, "translations")
+		case "/redirect-scheme":
+			http.Redirect(w, r, "ftp://127.0.0.1/export", http.StatusFound)
+		case "/redirect":
+			http.Redirect(w, r, "/export", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// localhost is an allowed host, which resolves to a private address
+	local := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name          string
+		hosts         []string
+		url           string
+		want          string
+		wantUntrusted bool
+	}{
+		{name: "allowed address", hosts: []string{"127.0.0.1"}, url: srv.URL + "/export", want: "translations"},
+		{name: "allowed redirect", hosts: []string{"127.0.0.1"}, url: srv.URL + "/redirect", want: "translations"},
+		{name: "private address of allowed host", hosts: []string{"localhost"}, url: local + "/export", wantUntrusted: true},
+		{name: "host not allowed", hosts: []string{"api.poeditor.com"}, url: srv.URL + "/export", wantUntrusted: true},
+		{name: "redirect to scheme not allowed", hosts: []string{"127.0.0.1"}, url: srv.URL + "/redirect-scheme", wantUntrusted: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDownloader(srv.Client(), []string{"http"}, tt.hosts)
+
+			body, err := d.Download(context.Background(), tt.url)
+
+			var untrusted *ErrUntrustedURL
+			if errors.As(err, &untrusted) != tt.wantUntrusted {
+				t.Fatalf("Download() error = %v, want untrusted %v", err, tt.wantUntrusted)
+			}
+
+			if tt.wantUntrusted {
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Download() error = %v", err)
+			}
+			defer body.Close()
+
+			b, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("Failed to read download: %v", err)
+			}
+
+			if string(b) != tt.want {
+				t.Errorf("Download() = %q, want %q", b, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloaderDialChecked(t *testing.T) {
+	proxied, err := NewHTTPClient(Config{Proxy: "http://proxy.example.com:3128"})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		want   bool
+	}{
+		{name: "transport", client: &http.Client{Transport: &http.Transport{}}, want: true},
+		{name: "limited transport", client: &http.Client{Transport: &limitedTransport{base: &http.Transport{}, dialer: &net.Dialer{}}}, want: true},
+		{name: "proxy", client: proxied, want: false},
+		{name: "unknown transport", client: &http.Client{Transport: roundTripperFunc(http.DefaultTransport.RoundTrip)}, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewDownloader(tt.client, []string{"https"}, nil).dialChecked; got != tt.want {
+				t.Errorf("dialChecked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestMaxResponseSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") != "" {
+			w.(http.Flusher).Flush()
+		}
+
+		io.WriteString(w, r.URL.Query().Get("body"))
+	}))
+	defer srv.Close()
+
+	client, err := NewHTTPClient(Config{MaxResponseSize: 5})
+	if err != nil {
+		t.Fatalf("NewHTTPClient() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "smaller", query: "body=abc"},
+		{name: "exactly max size", query: "body=abcde"},
+		{name: "larger", query: "body=abcdef", wantErr: true},
+		{name: "larger without length", query: "body=abcdef&chunked=1", wantErr: true},
+		{name: "exactly max size without length", query: "body=abcde&chunked=1"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := client.Get(srv.URL + "?" + tt.query)
+			if err == nil {
+				defer res.Body.Close()
+				_, err = io.ReadAll(res.Body)
+			}
+
+			if tt.wantErr != errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("Get() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+const (
+	defaultTokenKey = "default"
+	tokenFilePrefix = "file:"
+)
+
+// ErrNoAPIToken is returned when no poeditor api token is configured for a project.
+type ErrNoAPIToken struct {
+	ProjectID int
+}
+
+func (e *ErrNoAPIToken) Error() string {
+	return fmt.Sprintf("No api token configured for project %d", e.ProjectID)
+}
+
+// ClientResolver resolves the poeditor client to use for a project.
+type ClientResolver interface {
+	Client(projectID int) (client poedit.Client, err error)
+}
+
+// tokenSource is an api token, either given directly or read from a file.
+// Tokens read from a file are re-read when the file changes, such that they can be rotated without a restart.
+type tokenSource struct {
+	token   string
+	file    string
+	modTime time.Time
+	mutex   *sync.Mutex
+}
+
+func newTokenSource(s string) *tokenSource {
+	if strings.HasPrefix(s, tokenFilePrefix) {
+		return &tokenSource{
+			file:  strings.TrimPrefix(s, tokenFilePrefix),
+			mutex: &sync.Mutex{},
+		}
+	}
+
+	return &tokenSource{
+		token: s,
+		mutex: &sync.Mutex{},
+	}
+}
+
+func (t *tokenSource) get() (string, error) {
+	if t.file == "" {
+		return t.token, nil
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	info, err := os.Stat(t.file)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to read api token file '%s'", t.file)
+	}
+
+	if info.ModTime().Equal(t.modTime) {
+		return t.token, nil
+	}
+
+	b, err := os.ReadFile(t.file)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to read api token file '%s'", t.file)
+	}
+
+	t.token = strings.TrimSpace(string(b))
+	t.modTime = info.ModTime()
+
+	return t.token, nil
+}
+
+// tokenRange maps an inclusive range of project ids to a token.
+type tokenRange struct {
+	from, to int
+	token    *tokenSource
+}
+
+// TokenResolver resolves poeditor clients from a map of api tokens.
+// The map is keyed by a project id, an inclusive range of project ids such as "1000-1999", or "default".
+// A project id takes precedence over a range, the narrowest range over wider ones, and any range over the default.
+type TokenResolver struct {
+	httpClient *http.Client
+	opts       []poedit.Option
+	mutex      *sync.RWMutex
+	projects   map[int]*tokenSource
+	ranges     []tokenRange
+	fallback   *tokenSource
+	clients    map[string]*poedit.ClientImpl
+}
+
+func NewTokenResolver(tokens map[string]string, httpClient *http.Client, opts ...poedit.Option) (*TokenResolver, error) {
+	r := &TokenResolver{
+		httpClient: httpClient,
+		opts:       opts,
+		mutex:      &sync.RWMutex{},
+		clients:    map[string]*poedit.ClientImpl{},
+	}
+
+	if err := r.SetTokens(tokens); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// SetTokens replaces the token map of the resolver.
+func (r *TokenResolver) SetTokens(tokens map[string]string) error {
+	projects := map[int]*tokenSource{}
+	ranges := []tokenRange{}
+	var fallback *tokenSource
+	sources := []*tokenSource{}
+
+	for key, token := range tokens {
+		if token == "" {
+			continue
+		}
+
+		source := newTokenSource(token)
+		sources = append(sources, source)
+
+		if key == defaultTokenKey {
+			fallback = source
+
+			continue
+		}
+
+		if bounds := strings.SplitN(key, "-", 2); len(bounds) == 2 {
+			f, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+			if err != nil {
+				return errors.Errorf("Invalid project range '%s'", key)
+			}
+
+			t, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
+			if err != nil || t < f {
+				return errors.Errorf("Invalid project range '%s'", key)
+			}
+
+			ranges = append(ranges, tokenRange{from: f, to: t, token: source})
+
+			continue
+		}
+
+		id, err := strconv.Atoi(key)
+		if err != nil {
+			return errors.Errorf("Invalid project '%s' in api tokens", key)
+		}
+
+		projects[id] = source
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].to-ranges[i].from < ranges[j].to-ranges[j].from
+	})
+
+	// Clients are only kept for the tokens still configured, such that replaced tokens do not pile up
+	configured := map[string]bool{}
+	for _, s := range sources {
+		if token, err := s.get(); err == nil {
+			configured[token] = true
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.projects = projects
+	r.ranges = ranges
+	r.fallback = fallback
+
+	for token := range r.clients {
+		if !configured[token] {
+			delete(r.clients, token)
+		}
+	}
+
+	return nil
+}
+
+// Client returns the poeditor client for the api token of a project.
+func (r *TokenResolver) Client(projectID int) (poedit.Client, error) {
+	source := r.tokenSource(projectID)
+	if source == nil {
+		return nil, &ErrNoAPIToken{ProjectID: projectID}
+	}
+
+	token, err := source.get()
+	if err != nil {
+		return nil, err
+	}
+
+	return r.client(token), nil
+}
+
+// Clients returns a poeditor client for every distinct api token, i.e. one per poeditor account.
+func (r *TokenResolver) Clients() ([]poedit.Client, error) {
+	r.mutex.RLock()
+	sources := make([]*tokenSource, 0, len(r.projects)+len(r.ranges)+1)
+	for _, s := range r.projects {
+		sources = append(sources, s)
+	}
+	for _, rng := range r.ranges {
+		sources = append(sources, rng.token)
+	}
+	if r.fallback != nil {
+		sources = append(sources, r.fallback)
+	}
+	r.mutex.RUnlock()
+
+	seen := map[string]bool{}
+	clients := []poedit.Client{}
+
+	for _, s := range sources {
+		token, err := s.get()
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+
+		clients = append(clients, r.client(token))
+	}
+
+	return clients, nil
+}
+
+// Verify checks every api token by listing the projects of its account, and returns the errors by the key of the token in the token map.
+func (r *TokenResolver) Verify(ctx context.Context) map[string]error {
+	r.mutex.RLock()
+	sources := make(map[string]*tokenSource, len(r.projects)+len(r.ranges)+1)
+	for id, s := range r.projects {
+		sources[strconv.Itoa(id)] = s
+	}
+	for _, rng := range r.ranges {
+		sources[fmt.Sprintf("%d-%d", rng.from, rng.to)] = rng.token
+	}
+	if r.fallback != nil {
+		sources[defaultTokenKey] = r.fallback
+	}
+	r.mutex.RUnlock()
+
+	errs := map[string]error{}
+
+	for key, s := range sources {
+		token, err := s.get()
+		if err != nil {
+			errs[key] = err
+
+			continue
+		}
+
+		if _, err := r.client(token).ListProjects(ctx); err != nil {
+			errs[key] = err
+		}
+	}
+
+	return errs
+}
+
+func (r *TokenResolver) tokenSource(projectID int) *tokenSource {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if s, ok := r.projects[projectID]; ok {
+		return s
+	}
+
+	for _, rng := range r.ranges {
+		if projectID >= rng.from && projectID <= rng.to {
+			return rng.token
+		}
+	}
+
+	return r.fallback
+}
+
+// client returns the client of a token, reusing clients across calls.
+func (r *TokenResolver) client(token string) *poedit.ClientImpl {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c, ok := r.clients[token]
+	if !ok {
+		c = poedit.NewClient(token, r.httpClient, r.opts...)
+		r.clients[token] = c
+	}
+
+	return c
+}
@@ -0,0 +1,127 @@
+package project
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+func TestTokenResolverClient(t *testing.T) {
+	tokens := map[string]string{
+		"default":   "fallback",
+		"1000-1999": "wide",
+		"1500-1599": "narrow",
+		"1550":      "project",
+		"2000":      "",
+	}
+
+	r, err := NewTokenResolver(tokens, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create token resolver: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		projectID int
+		want      string
+	}{
+		{name: "project", projectID: 1550, want: "project"},
+		{name: "narrowest range", projectID: 1551, want: "narrow"},
+		{name: "wider range", projectID: 1001, want: "wide"},
+		{name: "default", projectID: 5, want: "fallback"},
+		{name: "empty token", projectID: 2000, want: "fallback"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := r.Client(tt.projectID)
+			if err != nil {
+				t.Fatalf("Client() error = %v", err)
+			}
+
+			if c != r.client(tt.want) {
+				t.Errorf("Client(%d) is not the client of token %q", tt.projectID, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenResolverNoToken(t *testing.T) {
+	r, err := NewTokenResolver(map[string]string{"1": "token"}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create token resolver: %v", err)
+	}
+
+	if _, err := r.Client(2); err == nil {
+		t.Fatal("Client() expected an error for a project without a token")
+	}
+}
+
+func TestTokenResolverInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens map[string]string
+	}{
+		{name: "project", tokens: map[string]string{"abc": "token"}},
+		{name: "range bound", tokens: map[string]string{"1-x": "token"}},
+		{name: "reversed range", tokens: map[string]string{"10-1": "token"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTokenResolver(tt.tokens, http.DefaultClient); err == nil {
+				t.Errorf("NewTokenResolver(%v) expected an error", tt.tokens)
+			}
+		})
+	}
+}
+
+func TestTokenResolverSetTokens(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+
+	r, err := NewTokenResolver(map[string]string{"1": "old", "2": "kept"}, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("Failed to create token resolver: %v", err)
+	}
+
+	if _, err := r.Clients(); err != nil {
+		t.Fatalf("Clients() error = %v", err)
+	}
+	kept := r.client("kept")
+
+	if err := r.SetTokens(map[string]string{"2": "kept", "3": tokenFilePrefix + file}); err != nil {
+		t.Fatalf("SetTokens() error = %v", err)
+	}
+
+	if _, ok := r.clients["old"]; ok {
+		t.Error("SetTokens() kept the client of a token no longer configured")
+	}
+
+	if c := r.client("kept"); c != kept {
+		t.Error("SetTokens() replaced the client of a token still configured")
+	}
+
+	clients, err := r.Clients()
+	if err != nil {
+		t.Fatalf("Clients() error = %v", err)
+	}
+
+	want := map[poedit.Client]bool{r.client("kept"): true, r.client("from-file"): true}
+	if len(clients) != len(want) {
+		t.Fatalf("Clients() returned %d clients, want %d", len(clients), len(want))
+	}
+
+	for _, c := range clients {
+		if !want[c] {
+			t.Errorf("Clients() returned a client of an unexpected token")
+		}
+	}
+}
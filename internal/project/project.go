@@ -0,0 +1,167 @@
+package project
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+type Project struct {
+	ID                int
+	Name              string
+	ReferenceLanguage string
+	Terms             int64
+	Updated           time.Time
+	TTL               time.Duration
+}
+
+type projectCacheItem struct {
+	project   *Project
+	expiresAt time.Time
+}
+
+// projectCache is a small in-memory cache of project metadata, with a ttl of its own.
+type projectCache struct {
+	ttl   time.Duration
+	items map[int]projectCacheItem
+	mutex *sync.RWMutex
+}
+
+func newProjectCache(ttl time.Duration) *projectCache {
+	return &projectCache{
+		ttl:   ttl,
+		items: map[int]projectCacheItem{},
+		mutex: &sync.RWMutex{},
+	}
+}
+
+func (c *projectCache) get(projectID int) (*Project, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	item, ok := c.items[projectID]
+	if !ok || time.Now().After(item.expiresAt) {
+		return nil, false
+	}
+
+	return item.project, true
+}
+
+func (c *projectCache) set(p *Project) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.items[p.ID] = projectCacheItem{
+		project:   p,
+		expiresAt: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *projectCache) getTTL() time.Duration {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	return c.ttl
+}
+
+// setTTL changes the ttl of projects cached from now on.
+func (c *projectCache) setTTL(ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.ttl = ttl
+}
+
+func (c *projectCache) purge(projectID int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.items, projectID)
+}
+
+// GetProject returns the metadata of a project, served from the project cache when possible.
+func (s *ServiceImpl) GetProject(ctx context.Context, projectID int) (*Project, error) {
+	if p, ok := s.Projects.get(projectID); ok {
+		return p, nil
+	}
+
+	release, err := s.acquireUpstream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return s.refreshProject(ctx, projectID)
+}
+
+// refreshProject fetches the metadata of a project from poeditor and stores it in the project cache.
+//
+// Poeditor does not expose an update time on the project itself,
+// so the latest update of any of the project languages is used instead.
+func (s *ServiceImpl) refreshProject(ctx context.Context, projectID int) (*Project, error) {
+	cli, err := s.Clients.Client(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	view, err := cli.ViewProject(ctx, poedit.ViewProjectRequest{
+		ID: projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	languagesUpdated, err := s.languagesUpdated(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated time.Time
+	for _, t := range languagesUpdated {
+		if t.After(updated) {
+			updated = t
+		}
+	}
+
+	p := &Project{
+		ID:                projectID,
+		Name:              view.Result.Project.Name,
+		ReferenceLanguage: view.Result.Project.ReferenceLanguage,
+		Terms:             view.Result.Project.Terms,
+		Updated:           updated,
+		TTL:               s.Projects.getTTL(),
+	}
+
+	s.Projects.set(p)
+
+	return p, nil
+}
+
+// languagesUpdated returns the time of the latest change to each language of a project, by language code.
+func (s *ServiceImpl) languagesUpdated(ctx context.Context, projectID int) (map[string]time.Time, error) {
+	cli, err := s.Clients.Client(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	languages, err := cli.ListProjectLanguages(ctx, poedit.ListProjectLanguagesRequest{
+		ID: projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make(map[string]time.Time, len(languages.Result.Languages))
+	for _, l := range languages.Result.Languages {
+		t, err := poedit.ParseTime(l.Updated)
+		if err != nil {
+			return nil, err
+		}
+
+		updated[l.Code] = t
+	}
+
+	return updated, nil
+}
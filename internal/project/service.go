@@ -1,9 +1,10 @@
 package project
 
 import (
+	"bytes"
 	"context"
-	"io/ioutil"
-	"net/http"
+	"io"
+	"sync/atomic"
 	"time"
 
 	gosundheit "github.com/AppsFlyer/go-sundheit"
@@ -11,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/uniwise/parrot/internal/cache"
+	"github.com/uniwise/parrot/internal/outbound"
 	"github.com/uniwise/parrot/pkg/poedit"
 	"golang.org/x/sync/semaphore"
 )
@@ -23,26 +25,34 @@ type Translation struct {
 
 type Service interface {
 	GetTranslation(ctx context.Context, projectID int, languageCode, format string) (trans *Translation, err error)
+	GetProject(ctx context.Context, projectID int) (project *Project, err error)
 	PurgeTranslation(ctx context.Context, projectID int, languageCode string) (err error)
 	PurgeProject(ctx context.Context, projectID int) (err error)
 	RegisterChecks(h gosundheit.Health) (err error)
 }
 
 type ServiceImpl struct {
-	Logger            *logrus.Entry
-	Client            poedit.Client
-	Cache             cache.Cache
-	RenewalThreshold  time.Duration
+	Logger  *logrus.Entry
+	Clients ClientResolver
+	Cache   cache.Cache
+	// renewalThreshold is accessed atomically, as it may be changed while serving
+	renewalThreshold  int64
 	PreFetchSemaphore *semaphore.Weighted
+	FetchQueue        *FetchQueue
+	Downloader        *outbound.Downloader
+	Projects          *projectCache
 }
 
-func NewService(cli poedit.Client, cache cache.Cache, renewalThreshold time.Duration, entry *logrus.Entry) *ServiceImpl {
+func NewService(clients ClientResolver, cache cache.Cache, fetchQueue *FetchQueue, downloader *outbound.Downloader, renewalThreshold, projectTTL time.Duration, entry *logrus.Entry) *ServiceImpl {
 	return &ServiceImpl{
 		Logger:            entry,
-		Client:            cli,
+		Clients:           clients,
 		Cache:             cache,
-		RenewalThreshold:  renewalThreshold,
+		FetchQueue:        fetchQueue,
+		Downloader:        downloader,
+		renewalThreshold:  int64(renewalThreshold),
 		PreFetchSemaphore: semaphore.NewWeighted(1),
+		Projects:          newProjectCache(projectTTL),
 	}
 }
 
@@ -54,21 +64,8 @@ func (s *ServiceImpl) GetTranslation(ctx context.Context, projectID int, languag
 	if err == nil {
 		expiresAt := item.CreatedAt.Add(s.Cache.GetTTL())
 
-		if time.Until(expiresAt) < s.RenewalThreshold {
-			go func() {
-				if !s.PreFetchSemaphore.TryAcquire(1) {
-					return
-				}
-
-				defer s.PreFetchSemaphore.Release(1)
-
-				s.Logger.Debugf("Pre-fetching language %s format %s for project %d", languageCode, format, projectID)
-
-				_, _, err := s.fetchAndCacheTranslation(context.Background(), projectID, languageCode, format)
-				if err != nil {
-					s.Logger.Errorf("Failed to pre-fetch language %s format %s for project %d", languageCode, format, projectID)
-				}
-			}()
+		if time.Until(expiresAt) < time.Duration(atomic.LoadInt64(&s.renewalThreshold)) {
+			go s.preFetchTranslation(projectID, languageCode, format, item)
 		}
 
 		return &Translation{
@@ -78,7 +75,22 @@ func (s *ServiceImpl) GetTranslation(ctx context.Context, projectID int, languag
 		}, nil
 	}
 
-	data, checksum, err := s.fetchAndCacheTranslation(ctx, projectID, languageCode, format)
+	release, err := s.acquireUpstream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Store the update time of the language along with it, such that renewals can skip the export while it is unchanged
+	updated, err := s.languagesUpdated(ctx, projectID)
+	if err != nil {
+		s.Logger.WithError(err).Warnf("Failed to get update time of language %s for project %d", languageCode, projectID)
+	}
+
+	// Keep a copy of the translation as it streams into the cache, to serve it right away
+	var data bytes.Buffer
+
+	checksum, err := s.fetchAndCacheTranslation(ctx, projectID, languageCode, format, updated[languageCode], &data)
 	if err != nil {
 		return nil, err
 	}
@@ -86,51 +98,116 @@ func (s *ServiceImpl) GetTranslation(ctx context.Context, projectID int, languag
 	return &Translation{
 		TTL:      s.Cache.GetTTL(),
 		Checksum: checksum,
-		Data:     data,
+		Data:     data.Bytes(),
 	}, nil
 }
 
+// SetPolicies changes the threshold at which cached translations are renewed, and the ttl of cached project metadata.
+func (s *ServiceImpl) SetPolicies(renewalThreshold, projectTTL time.Duration) {
+	atomic.StoreInt64(&s.renewalThreshold, int64(renewalThreshold))
+	s.Projects.setTTL(projectTTL)
+}
+
+// preFetchTranslation renews a cached translation before it expires.
+// If poeditor reports the language unchanged since the translation was cached,
+// the cached item is renewed as is, instead of exporting it again.
+func (s *ServiceImpl) preFetchTranslation(projectID int, languageCode, format string, item *cache.CacheItem) {
+	if !s.PreFetchSemaphore.TryAcquire(1) {
+		return
+	}
+
+	defer s.PreFetchSemaphore.Release(1)
+
+	ctx := context.Background()
+
+	release, err := s.FetchQueue.Acquire(ctx)
+	if err != nil {
+		s.Logger.WithError(err).Warnf("Skipped pre-fetching language %s format %s for project %d", languageCode, format, projectID)
+
+		return
+	}
+	defer release()
+
+	updated, err := s.languagesUpdated(ctx, projectID)
+	if err != nil {
+		s.Logger.WithError(err).Warnf("Failed to check project %d for changes", projectID)
+	}
+
+	languageUpdated := updated[languageCode]
+
+	if !languageUpdated.IsZero() && languageUpdated.Equal(item.Updated) {
+		s.Logger.Debugf("Language %s unchanged for project %d, renewing format %s without export", languageCode, projectID, format)
+
+		if err := s.Cache.RenewTranslation(ctx, projectID, languageCode, format); err != nil {
+			s.Logger.WithError(err).Errorf("Failed to renew language %s format %s for project %d", languageCode, format, projectID)
+		}
+
+		return
+	}
+
+	s.Logger.Debugf("Pre-fetching language %s format %s for project %d", languageCode, format, projectID)
+
+	_, err = s.fetchAndCacheTranslation(ctx, projectID, languageCode, format, languageUpdated, nil)
+	if err != nil {
+		s.Logger.Errorf("Failed to pre-fetch language %s format %s for project %d", languageCode, format, projectID)
+	}
+}
+
+// acquireUpstream admits a request to fetch from poeditor, subject to the upstream guard of the request and the fetch queue.
+// The returned function must be called when the fetch is done.
+func (s *ServiceImpl) acquireUpstream(ctx context.Context) (func(), error) {
+	if err := checkUpstreamGuard(ctx); err != nil {
+		return nil, err
+	}
+
+	return s.FetchQueue.Acquire(ctx)
+}
+
 func (s *ServiceImpl) PurgeTranslation(ctx context.Context, projectID int, languageCode string) error {
-	return errors.New("Not implemented")
+	return s.Cache.PurgeTranslation(ctx, projectID, languageCode)
 }
 
 func (s *ServiceImpl) PurgeProject(ctx context.Context, projectID int) error {
-	return errors.New("Not implemented")
+	s.Projects.purge(projectID)
+
+	return s.Cache.PurgeProject(ctx, projectID)
 }
 
-func (s *ServiceImpl) fetchAndCacheTranslation(ctx context.Context, projectID int, languageCode, format string) ([]byte, string, error) {
-	resp, err := s.Client.ExportProject(ctx, poedit.ExportProjectRequest{
+// fetchAndCacheTranslation exports a translation from poeditor, and streams it into the cache.
+// The translation is also copied to w as it streams, unless w is nil, for callers which serve it right away.
+func (s *ServiceImpl) fetchAndCacheTranslation(ctx context.Context, projectID int, languageCode, format string, updated time.Time, w io.Writer) (string, error) {
+	cli, err := s.Clients.Client(projectID)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := cli.ExportProject(ctx, poedit.ExportProjectRequest{
 		ID:       projectID,
 		Language: languageCode,
 		Type:     format,
 		Filters:  []string{"translated"},
 	})
 	if err != nil {
-		return nil, "", err
+		return "", err
 	}
 
-	// TODO: Make use of injected http client, to support timeouts
-	d, err := http.Get(resp.Result.URL)
+	body, err := s.Downloader.Download(ctx, resp.Result.URL)
 	if err != nil {
-		return nil, "", err
-	}
-	defer d.Body.Close()
-
-	if d.StatusCode != http.StatusOK {
-		return nil, "", errors.Errorf("Response code '%d' from download GET", d.StatusCode)
-	}
+		var untrusted *outbound.ErrUntrustedURL
+		if errors.As(err, &untrusted) {
+			s.Logger.WithError(err).WithField("project", projectID).Error("Refused to download export")
+		}
 
-	data, err := ioutil.ReadAll(d.Body)
-	if err != nil {
-		return nil, "", err
+		return "", err
 	}
+	defer body.Close()
 
-	checksum, err := s.Cache.SetTranslation(ctx, projectID, languageCode, format, data)
-	if err != nil {
-		return nil, "", err
+	var r io.Reader = body
+	if w != nil {
+		r = io.TeeReader(body, w)
 	}
 
-	return data, checksum, nil
+	return s.Cache.SetTranslation(ctx, projectID, languageCode, format, updated, r)
 }
 
 func (s *ServiceImpl) RegisterChecks(h gosundheit.Health) error {
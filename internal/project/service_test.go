@@ -0,0 +1,253 @@
+package project
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/uniwise/parrot/internal/cache"
+	"github.com/uniwise/parrot/internal/outbound"
+	"github.com/uniwise/parrot/pkg/poedit"
+	"github.com/uniwise/parrot/pkg/poedit/poedittest"
+)
+
+const (
+	testProjectID = 1
+	testFormat    = "key_value_json"
+	exportPath    = "/v2/projects/export"
+)
+
+func newTestService(t *testing.T, s *poedittest.Server) *ServiceImpl {
+	t.Helper()
+
+	clients, err := NewTokenResolver(map[string]string{"default": "token"}, s.Client(),
+		poedit.WithHostURL(s.URL),
+		poedit.WithRetries(0, 0, 0),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create token resolver: %v", err)
+	}
+
+	c, err := cache.NewFilesystemCache(t.TempDir(), time.Hour)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	// The fake listens on localhost, which is only downloaded from when allowed explicitly
+	downloader := outbound.NewDownloader(s.Client(), []string{"https"}, []string{"127.0.0.1"})
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return NewService(clients, c, NewFetchQueue(1, 1), downloader, time.Minute, time.Minute, logrus.NewEntry(logger))
+}
+
+func newTestServer() *poedittest.Server {
+	s := poedittest.NewServer()
+
+	s.AddProject(testProjectID, "Website", "en")
+	s.AddTerm(testProjectID, poedit.Term{Term: "goodbye"})
+	s.SetTranslation(testProjectID, "da", poedit.TermKey{Term: "hello"}, poedit.TranslationContent{Singular: "Hej"})
+
+	return s
+}
+
+func decodeExport(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+
+	export := map[string]string{}
+	if err := json.Unmarshal(b, &export); err != nil {
+		t.Fatalf("Failed to unmarshal export %q: %v", b, err)
+	}
+
+	return export
+}
+
+func TestGetTranslation(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	svc := newTestService(t, s)
+	ctx := context.Background()
+
+	trans, err := svc.GetTranslation(ctx, testProjectID, "da", testFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	export := decodeExport(t, trans.Data)
+	if len(export) != 1 || export["hello"] != "Hej" {
+		t.Errorf("Expected only the translated term, got %v", export)
+	}
+
+	item, err := svc.Cache.GetTranslation(ctx, testProjectID, "da", testFormat)
+	if err != nil {
+		t.Fatalf("Expected the translation to be cached: %v", err)
+	}
+
+	if item.Checksum != trans.Checksum || string(item.Data) != string(trans.Data) {
+		t.Errorf("Expected the served translation to match the cached one, got %q and %q", trans.Data, item.Data)
+	}
+
+	p, _ := s.Project(testProjectID)
+	if !item.Updated.Equal(p.Languages["da"].Updated.Truncate(time.Second)) {
+		t.Errorf("Expected the update time of the language to be cached, got %s", item.Updated)
+	}
+
+	cached, err := svc.GetTranslation(ctx, testProjectID, "da", testFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cached.Checksum != trans.Checksum {
+		t.Errorf("Expected the cached translation, got checksum %s", cached.Checksum)
+	}
+
+	if n := s.Requests(exportPath); n != 1 {
+		t.Errorf("Expected a single export, got %d", n)
+	}
+}
+
+func TestGetTranslationFailedExport(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	s.Fail(exportPath, poedittest.CodePermissionDenied, "Permission denied")
+
+	svc := newTestService(t, s)
+	ctx := context.Background()
+
+	if _, err := svc.GetTranslation(ctx, testProjectID, "da", testFormat); err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if _, err := svc.Cache.GetTranslation(ctx, testProjectID, "da", testFormat); !errors.Is(err, cache.ErrCacheMiss) {
+		t.Errorf("Expected nothing to be cached, got %v", err)
+	}
+}
+
+func TestFetchAndCacheTranslation(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	svc := newTestService(t, s)
+	ctx := context.Background()
+
+	updated := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	checksum, err := svc.fetchAndCacheTranslation(ctx, testProjectID, "da", testFormat, updated, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	item, err := svc.Cache.GetTranslation(ctx, testProjectID, "da", testFormat)
+	if err != nil {
+		t.Fatalf("Expected the translation to be cached: %v", err)
+	}
+
+	if item.Checksum != checksum || !item.Updated.Equal(updated) {
+		t.Errorf("Expected checksum %s updated %s, got %s updated %s", checksum, updated, item.Checksum, item.Updated)
+	}
+
+	if export := decodeExport(t, item.Data); export["hello"] != "Hej" {
+		t.Errorf("Unexpected export %v", export)
+	}
+}
+
+func TestFetchAndCacheTranslationUntrustedURL(t *testing.T) {
+	s := newTestServer()
+	defer s.Close()
+
+	svc := newTestService(t, s)
+	svc.Downloader = outbound.NewDownloader(s.Client(), []string{"https"}, []string{"api.poeditor.com"})
+
+	_, err := svc.fetchAndCacheTranslation(context.Background(), testProjectID, "da", testFormat, time.Time{}, nil)
+
+	var untrusted *outbound.ErrUntrustedURL
+	if !errors.As(err, &untrusted) {
+		t.Errorf("Expected the download to be refused, got %v", err)
+	}
+}
+
+func TestPreFetchTranslation(t *testing.T) {
+	tests := []struct {
+		name string
+		// stale returns the update time of the cached item, given the update time poeditor reports for the language
+		stale   func(updated time.Time) time.Time
+		exports int
+	}{
+		{
+			name:    "unchanged language is renewed without export",
+			stale:   func(updated time.Time) time.Time { return updated },
+			exports: 1,
+		},
+		{
+			name:    "changed language is exported",
+			stale:   func(updated time.Time) time.Time { return updated.Add(-time.Hour) },
+			exports: 2,
+		},
+		{
+			name:    "unknown update time is exported",
+			stale:   func(updated time.Time) time.Time { return time.Time{} },
+			exports: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer()
+			defer s.Close()
+
+			svc := newTestService(t, s)
+			ctx := context.Background()
+
+			if _, err := svc.GetTranslation(ctx, testProjectID, "da", testFormat); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			item, err := svc.Cache.GetTranslation(ctx, testProjectID, "da", testFormat)
+			if err != nil {
+				t.Fatalf("Expected the translation to be cached: %v", err)
+			}
+
+			cachedAt := item.CreatedAt
+
+			// Translate the other term, such that a new export differs from the cached one
+			s.SetTranslation(testProjectID, "da", poedit.TermKey{Term: "goodbye"}, poedit.TranslationContent{Singular: "Farvel"})
+
+			p, _ := s.Project(testProjectID)
+
+			stale := *item
+			stale.Updated = tt.stale(p.Languages["da"].Updated.Truncate(time.Second))
+
+			// Let the renewal be told apart from the original by its creation time
+			time.Sleep(time.Millisecond * 10)
+
+			svc.preFetchTranslation(testProjectID, "da", testFormat, &stale)
+
+			if n := s.Requests(exportPath); n != tt.exports {
+				t.Errorf("Expected %d exports, got %d", tt.exports, n)
+			}
+
+			renewed, err := svc.Cache.GetTranslation(ctx, testProjectID, "da", testFormat)
+			if err != nil {
+				t.Fatalf("Expected the translation to still be cached: %v", err)
+			}
+
+			if !renewed.CreatedAt.After(cachedAt) {
+				t.Errorf("Expected the translation to be renewed, created at %s and before at %s", renewed.CreatedAt, cachedAt)
+			}
+
+			export := decodeExport(t, renewed.Data)
+			if exported := export["goodbye"] == "Farvel"; exported != (tt.exports > 1) {
+				t.Errorf("Unexpected translation %v after %d exports", export, tt.exports)
+			}
+		})
+	}
+}
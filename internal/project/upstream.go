@@ -0,0 +1,81 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sync/semaphore"
+)
+
+// ErrUpstreamSaturated is returned when too many fetches from poeditor are already queued.
+var ErrUpstreamSaturated = errors.New("Too many fetches from poeditor queued")
+
+// ErrRateLimited is returned when the caller has exhausted its budget of fetches from poeditor.
+type ErrRateLimited struct {
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	return fmt.Sprintf("Rate limited, retry after %s", e.RetryAfter)
+}
+
+// UpstreamGuard decides whether a request may trigger a fetch from poeditor.
+type UpstreamGuard func() error
+
+type upstreamGuardKey struct{}
+
+// WithUpstreamGuard returns a context, in which fetches from poeditor are subject to the guard.
+func WithUpstreamGuard(ctx context.Context, guard UpstreamGuard) context.Context {
+	return context.WithValue(ctx, upstreamGuardKey{}, guard)
+}
+
+func checkUpstreamGuard(ctx context.Context) error {
+	guard, ok := ctx.Value(upstreamGuardKey{}).(UpstreamGuard)
+	if !ok {
+		return nil
+	}
+
+	return guard()
+}
+
+// FetchQueue bounds the number of concurrent fetches from poeditor,
+// and sheds fetches when too many are already waiting.
+type FetchQueue struct {
+	semaphore *semaphore.Weighted
+	maxQueued int64
+	queued    int64
+}
+
+func NewFetchQueue(maxConcurrent, maxQueued int) *FetchQueue {
+	return &FetchQueue{
+		semaphore: semaphore.NewWeighted(int64(maxConcurrent)),
+		maxQueued: int64(maxQueued),
+	}
+}
+
+// Acquire waits for a fetch slot. The returned function must be called to release it.
+func (q *FetchQueue) Acquire(ctx context.Context) (func(), error) {
+	if q.semaphore.TryAcquire(1) {
+		return q.release, nil
+	}
+
+	if atomic.AddInt64(&q.queued, 1) > q.maxQueued {
+		atomic.AddInt64(&q.queued, -1)
+
+		return nil, ErrUpstreamSaturated
+	}
+	defer atomic.AddInt64(&q.queued, -1)
+
+	if err := q.semaphore.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	return q.release, nil
+}
+
+func (q *FetchQueue) release() {
+	q.semaphore.Release(1)
+}
@@ -0,0 +1,113 @@
+package project
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/internal/cache"
+)
+
+// WarmupTarget is a set of translations of a project to keep cached.
+type WarmupTarget struct {
+	Project int `mapstructure:"project"`
+	// Languages defaults to every language of the project.
+	Languages []string `mapstructure:"languages"`
+	Formats   []string `mapstructure:"formats"`
+}
+
+// WarmupResult is the outcome of warming a single translation.
+type WarmupResult struct {
+	ProjectID    int
+	LanguageCode string
+	Format       string
+	// Fetched is false if the translation was already cached and up to date.
+	Fetched  bool
+	Checksum string
+	Err      error
+}
+
+// Warm fetches the translations of the targets into the cache, unless they are cached and unchanged in poeditor.
+// A failure to warm one translation does not stop the others from being warmed.
+func (s *ServiceImpl) Warm(ctx context.Context, targets []WarmupTarget) []WarmupResult {
+	results := []WarmupResult{}
+
+	for _, target := range targets {
+		updated, err := s.languagesUpdated(ctx, target.Project)
+		if err != nil {
+			results = append(results, WarmupResult{
+				ProjectID: target.Project,
+				Err:       errors.Wrapf(err, "Failed to list languages of project %d", target.Project),
+			})
+
+			continue
+		}
+
+		languages := target.Languages
+		if len(languages) == 0 {
+			for code := range updated {
+				languages = append(languages, code)
+			}
+			sort.Strings(languages)
+		}
+
+		for _, code := range languages {
+			for _, format := range target.Formats {
+				results = append(results, s.warmTranslation(ctx, target.Project, code, format, updated))
+			}
+		}
+	}
+
+	return results
+}
+
+func (s *ServiceImpl) warmTranslation(ctx context.Context, projectID int, languageCode, format string, updated map[string]time.Time) WarmupResult {
+	result := WarmupResult{
+		ProjectID:    projectID,
+		LanguageCode: languageCode,
+		Format:       format,
+	}
+
+	languageUpdated, ok := updated[languageCode]
+	if !ok {
+		result.Err = errors.Errorf("Project %d does not contain language %s", projectID, languageCode)
+
+		return result
+	}
+
+	item, err := s.Cache.GetTranslation(ctx, projectID, languageCode, format)
+	if err != nil && !errors.Is(err, cache.ErrCacheMiss) {
+		result.Err = err
+
+		return result
+	}
+
+	if err == nil && !languageUpdated.IsZero() && languageUpdated.Equal(item.Updated) {
+		result.Checksum = item.Checksum
+
+		return result
+	}
+
+	release, err := s.FetchQueue.Acquire(ctx)
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+	defer release()
+
+	checksum, err := s.fetchAndCacheTranslation(ctx, projectID, languageCode, format, languageUpdated, nil)
+	if err != nil {
+		result.Err = err
+
+		return result
+	}
+
+	result.Fetched = true
+	result.Checksum = checksum
+
+	s.Logger.Debugf("Warmed language %s format %s for project %d", languageCode, format, projectID)
+
+	return result
+}
@@ -0,0 +1,266 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Operation is a kind of access to a project.
+type Operation string
+
+const (
+	OperationRead  Operation = "read"
+	OperationPurge Operation = "purge"
+	// OperationAdmin grants every other operation.
+	OperationAdmin Operation = "admin"
+
+	allProjects = "*"
+
+	principalContextKey = "principal"
+
+	// The authenticators which principals are authenticated by.
+	authenticatorAPIKey = "apikey"
+	authenticatorJWT    = "jwt"
+)
+
+var (
+	// ErrNoCredentials is returned by an authenticator when a request does not carry credentials it understands.
+	ErrNoCredentials = errors.New("No credentials in request")
+	// ErrInvalidCredentials is returned by an authenticator when a request carries credentials it can not accept.
+	ErrInvalidCredentials = errors.New("Invalid credentials")
+)
+
+// Principal is an authenticated caller and the access it has been granted.
+type Principal struct {
+	// Authenticator is the kind of credentials the principal is authenticated by, as names are only unique per kind.
+	Authenticator string
+	Name          string
+	AllProjects   bool
+	Projects      map[int]bool
+	Operations    map[Operation]bool
+}
+
+// Allows reports whether the principal may perform the operation on the project.
+func (p *Principal) Allows(projectID int, op Operation) bool {
+	if !p.AllProjects && !p.Projects[projectID] {
+		return false
+	}
+
+	return p.Operations[OperationAdmin] || p.Operations[op]
+}
+
+// GetPrincipal returns the principal authenticated for the request, if any.
+func GetPrincipal(ctx echo.Context) (*Principal, bool) {
+	p, ok := ctx.Get(principalContextKey).(*Principal)
+
+	return p, ok
+}
+
+// Authenticator resolves the principal of a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (principal *Principal, err error)
+}
+
+// AccessControl guards the api with a chain of authenticators.
+// The first authenticator to recognise the credentials of a request decides who the caller is.
+type AccessControl struct {
+	authenticators []Authenticator
+	logger         *logrus.Entry
+}
+
+func NewAccessControl(l *logrus.Entry, authenticators ...Authenticator) *AccessControl {
+	return &AccessControl{
+		authenticators: authenticators,
+		logger:         l,
+	}
+}
+
+// Middleware authenticates requests and authorizes them against the project and operation of the route.
+func (a *AccessControl) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			principal, err := a.authenticate(ctx.Request())
+			if err != nil {
+				a.logger.WithError(err).WithField("path", ctx.Request().URL.Path).Debug("Rejected unauthenticated request")
+				ctx.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="parrot"`)
+
+				return echo.ErrUnauthorized
+			}
+
+			projectID, err := strconv.Atoi(ctx.Param("project"))
+			if err != nil {
+				return echo.ErrBadRequest
+			}
+
+			op := requiredOperation(ctx)
+
+			if !principal.Allows(projectID, op) {
+				a.logger.WithFields(logrus.Fields{
+					"principal": principal.Name,
+					"project":   projectID,
+					"operation": op,
+				}).Info("Denied access to project")
+
+				return echo.ErrForbidden
+			}
+
+			ctx.Set(principalContextKey, principal)
+
+			return next(ctx)
+		}
+	}
+}
+
+func (a *AccessControl) authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range a.authenticators {
+		p, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+
+		return p, err
+	}
+
+	return nil, ErrNoCredentials
+}
+
+// requiredOperation returns the operation a route performs.
+func requiredOperation(ctx echo.Context) Operation {
+	switch {
+	case strings.HasSuffix(ctx.Path(), "/purge"):
+		return OperationPurge
+	case ctx.Request().Method == http.MethodGet, ctx.Request().Method == http.MethodHead:
+		return OperationRead
+	default:
+		return OperationAdmin
+	}
+}
+
+// APIKey is the configuration of a single api key.
+type APIKey struct {
+	Name       string      `mapstructure:"name"`
+	Key        string      `mapstructure:"key"`
+	Projects   []string    `mapstructure:"projects"`
+	Operations []Operation `mapstructure:"operations"`
+}
+
+// APIKeyAuthenticator authenticates requests carrying an api key as a bearer token.
+type APIKeyAuthenticator struct {
+	mutex *sync.RWMutex
+	keys  map[string]*Principal
+}
+
+func NewAPIKeyAuthenticator(keys []APIKey) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{
+		mutex: &sync.RWMutex{},
+	}
+
+	if err := a.SetKeys(keys); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// SetKeys replaces the accepted api keys.
+func (a *APIKeyAuthenticator) SetKeys(keys []APIKey) error {
+	principals := make(map[string]*Principal, len(keys))
+
+	for _, k := range keys {
+		if k.Key == "" {
+			return errors.Errorf("Api key '%s' has no key", k.Name)
+		}
+
+		p, err := newPrincipal(authenticatorAPIKey, k.Name, k.Projects, k.Operations)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid api key '%s'", k.Name)
+		}
+
+		principals[hashKey(k.Key)] = p
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.keys = principals
+
+	return nil
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	p, ok := a.keys[hashKey(key)]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	return p, nil
+}
+
+func newPrincipal(authenticator, name string, projects []string, operations []Operation) (*Principal, error) {
+	p := &Principal{
+		Authenticator: authenticator,
+		Name:          name,
+		Projects:      map[int]bool{},
+		Operations:    map[Operation]bool{},
+	}
+
+	for _, project := range projects {
+		if project == allProjects {
+			p.AllProjects = true
+
+			continue
+		}
+
+		id, err := strconv.Atoi(project)
+		if err != nil {
+			return nil, errors.Errorf("'%s' is not a project id", project)
+		}
+
+		p.Projects[id] = true
+	}
+
+	for _, op := range operations {
+		switch op {
+		case OperationRead, OperationPurge, OperationAdmin:
+			p.Operations[op] = true
+		default:
+			return nil, errors.Errorf("'%s' is not an operation", op)
+		}
+	}
+
+	return p, nil
+}
+
+// hashKey hashes api keys, such that looking them up does not leak their content through timing.
+func hashKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(h[:])
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	const prefix = "Bearer "
+
+	h := r.Header.Get(echo.HeaderAuthorization)
+	if len(h) <= len(prefix) || !strings.EqualFold(h[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return h[len(prefix):], true
+}
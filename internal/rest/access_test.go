@@ -0,0 +1,196 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+func testLogger() *logrus.Entry {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return logrus.NewEntry(logger)
+}
+
+func newBearerRequest(method, target, token string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+
+	return req
+}
+
+func TestPrincipalAllows(t *testing.T) {
+	tests := []struct {
+		name       string
+		projects   []string
+		operations []Operation
+		projectID  int
+		op         Operation
+		want       bool
+	}{
+		{name: "granted", projects: []string{"1"}, operations: []Operation{OperationRead}, projectID: 1, op: OperationRead, want: true},
+		{name: "other project", projects: []string{"1"}, operations: []Operation{OperationRead}, projectID: 2, op: OperationRead, want: false},
+		{name: "other operation", projects: []string{"1"}, operations: []Operation{OperationRead}, projectID: 1, op: OperationPurge, want: false},
+		{name: "all projects", projects: []string{"*"}, operations: []Operation{OperationPurge}, projectID: 7, op: OperationPurge, want: true},
+		{name: "admin", projects: []string{"1"}, operations: []Operation{OperationAdmin}, projectID: 1, op: OperationPurge, want: true},
+		{name: "admin of other project", projects: []string{"1"}, operations: []Operation{OperationAdmin}, projectID: 2, op: OperationRead, want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newPrincipal(authenticatorAPIKey, "test", tt.projects, tt.operations)
+			if err != nil {
+				t.Fatalf("newPrincipal() error = %v", err)
+			}
+
+			if got := p.Allows(tt.projectID, tt.op); got != tt.want {
+				t.Errorf("Allows(%d, %s) = %v, want %v", tt.projectID, tt.op, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator([]APIKey{
+		{Name: "reader", Key: "secret", Projects: []string{"1"}, Operations: []Operation{OperationRead}},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		header   string
+		wantName string
+		wantErr  error
+	}{
+		{name: "valid key", header: "Bearer secret", wantName: "reader"},
+		{name: "lower case scheme", header: "bearer secret", wantName: "reader"},
+		{name: "unknown key", header: "Bearer other", wantErr: ErrInvalidCredentials},
+		{name: "no header", header: "", wantErr: ErrNoCredentials},
+		{name: "other scheme", header: "Basic secret", wantErr: ErrNoCredentials},
+		{name: "empty token", header: "Bearer ", wantErr: ErrNoCredentials},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(echo.HeaderAuthorization, tt.header)
+			}
+
+			p, err := a.Authenticate(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr != nil {
+				return
+			}
+
+			if p.Name != tt.wantName || p.Authenticator != authenticatorAPIKey {
+				t.Errorf("Authenticate() = %s:%s, want %s:%s", p.Authenticator, p.Name, authenticatorAPIKey, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticatorSetKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []APIKey
+		wantErr bool
+	}{
+		{name: "valid", keys: []APIKey{{Name: "a", Key: "k", Projects: []string{"*"}, Operations: []Operation{OperationAdmin}}}},
+		{name: "no key", keys: []APIKey{{Name: "a", Projects: []string{"1"}}}, wantErr: true},
+		{name: "invalid project", keys: []APIKey{{Name: "a", Key: "k", Projects: []string{"web"}}}, wantErr: true},
+		{name: "invalid operation", keys: []APIKey{{Name: "a", Key: "k", Projects: []string{"1"}, Operations: []Operation{"write"}}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewAPIKeyAuthenticator(tt.keys)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewAPIKeyAuthenticator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticatorKeepsKeysOnInvalidSet(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator([]APIKey{{Name: "a", Key: "old", Projects: []string{"1"}}})
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator() error = %v", err)
+	}
+
+	if err := a.SetKeys([]APIKey{{Name: "b", Projects: []string{"1"}}}); err == nil {
+		t.Fatal("SetKeys() expected an error for a key without a key")
+	}
+
+	if _, err := a.Authenticate(newBearerRequest(http.MethodGet, "/", "old")); err != nil {
+		t.Errorf("Authenticate() error = %v, want the previous keys to be kept", err)
+	}
+}
+
+func TestAccessControlMiddleware(t *testing.T) {
+	keys, err := NewAPIKeyAuthenticator([]APIKey{
+		{Name: "reader", Key: "reader", Projects: []string{"1"}, Operations: []Operation{OperationRead}},
+		{Name: "purger", Key: "purger", Projects: []string{"*"}, Operations: []Operation{OperationPurge}},
+	})
+	if err != nil {
+		t.Fatalf("NewAPIKeyAuthenticator() error = %v", err)
+	}
+
+	e := echo.New()
+	g := e.Group("/projects/:project", NewAccessControl(testLogger(), keys).Middleware())
+	ok := func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	}
+	g.GET("/translations/:language", ok)
+	g.POST("/translations/purge", ok)
+	g.POST("/translations/:language", ok)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		token  string
+		want   int
+	}{
+		{name: "read", method: http.MethodGet, target: "/projects/1/translations/en", token: "reader", want: http.StatusOK},
+		{name: "read other project", method: http.MethodGet, target: "/projects/2/translations/en", token: "reader", want: http.StatusForbidden},
+		{name: "purge without grant", method: http.MethodPost, target: "/projects/1/translations/purge", token: "reader", want: http.StatusForbidden},
+		{name: "purge", method: http.MethodPost, target: "/projects/1/translations/purge", token: "purger", want: http.StatusOK},
+		{name: "admin route", method: http.MethodPost, target: "/projects/1/translations/en", token: "purger", want: http.StatusForbidden},
+		{name: "unknown key", method: http.MethodGet, target: "/projects/1/translations/en", token: "other", want: http.StatusUnauthorized},
+		{name: "no credentials", method: http.MethodGet, target: "/projects/1/translations/en", want: http.StatusUnauthorized},
+		{name: "invalid project", method: http.MethodGet, target: "/projects/web/translations/en", token: "reader", want: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, newBearerRequest(tt.method, tt.target, tt.token))
+
+			if rec.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.target, rec.Code, tt.want)
+			}
+
+			if tt.want == http.StatusUnauthorized && rec.Header().Get(echo.HeaderWWWAuthenticate) == "" {
+				t.Error("Unauthorized response without a WWW-Authenticate header")
+			}
+		})
+	}
+}
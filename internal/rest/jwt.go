@@ -0,0 +1,344 @@
+package rest
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const jwksFetchTimeout = time.Second * 10
+
+var jwtSigningMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// JWKS is a set of public keys for verifying jwt signatures, loaded from a file or an url.
+type JWKS struct {
+	source string
+	client *http.Client
+	mutex  *sync.RWMutex
+	keys   map[string]interface{}
+}
+
+func NewJWKS(source string) (*JWKS, error) {
+	j := &JWKS{
+		source: source,
+		client: &http.Client{Timeout: jwksFetchTimeout},
+		mutex:  &sync.RWMutex{},
+	}
+
+	if err := j.Refresh(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// Refresh reloads the keys from the source. The current keys are kept if the source can not be loaded.
+func (j *JWKS) Refresh(ctx context.Context) error {
+	b, err := j.load(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to load jwks from '%s'", j.source)
+	}
+
+	keys, err := parseJWKS(b)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to parse jwks from '%s'", j.source)
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.keys = keys
+
+	return nil
+}
+
+// RefreshPeriodically refreshes the keys every interval, until the context is done.
+func (j *JWKS) RefreshPeriodically(ctx context.Context, interval time.Duration, l *logrus.Entry) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.Refresh(ctx); err != nil {
+				l.WithError(err).Error("Failed to refresh jwks")
+			}
+		}
+	}
+}
+
+func (j *JWKS) key(kid string) (interface{}, bool) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+
+	k, ok := j.keys[kid]
+
+	return k, ok
+}
+
+func (j *JWKS) load(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(j.source, "http://") && !strings.HasPrefix(j.source, "https://") {
+		return os.ReadFile(j.source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := j.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Response code '%d' from jwks GET", res.StatusCode)
+	}
+
+	return io.ReadAll(res.Body)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses the signature keys of a json web key set, by key id.
+func parseJWKS(b []byte) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		var (
+			key interface{}
+			err error
+		)
+
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAKey(k)
+		case "EC":
+			key, err = parseECKey(k)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid key '%s'", k.Kid)
+		}
+
+		keys[k.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func parseRSAKey(k jsonWebKey) (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := decodeBigInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: n,
+		E: int(e.Int64()),
+	}, nil
+}
+
+func parseECKey(k jsonWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, errors.Errorf("Unsupported curve '%s'", k.Crv)
+	}
+
+	x, err := decodeBigInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := decodeBigInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{
+		Curve: curve,
+		X:     x,
+		Y:     y,
+	}, nil
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWTAuthenticator authenticates requests carrying a jwt as a bearer token.
+// The projects the caller may read are taken from a claim of the token.
+type JWTAuthenticator struct {
+	jwks          *JWKS
+	issuer        string
+	audience      string
+	projectsClaim string
+	parser        *jwt.Parser
+}
+
+// NewJWTAuthenticator returns an authenticator of jwts signed by a key of the jwks.
+// The issuer and audience are required, as every jwt would be rejected without them.
+func NewJWTAuthenticator(jwks *JWKS, issuer, audience, projectsClaim string) (*JWTAuthenticator, error) {
+	if issuer == "" || audience == "" {
+		return nil, errors.New("Jwts require an issuer and an audience to verify")
+	}
+
+	return &JWTAuthenticator{
+		jwks:          jwks,
+		issuer:        issuer,
+		audience:      audience,
+		projectsClaim: projectsClaim,
+		parser: &jwt.Parser{
+			ValidMethods: jwtSigningMethods,
+		},
+	}, nil
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok || strings.Count(token, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+
+	if _, err := a.parser.ParseWithClaims(token, claims, a.keyFunc); err != nil {
+		return nil, errors.Wrap(ErrInvalidCredentials, err.Error())
+	}
+
+	now := time.Now().Unix()
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.Wrap(ErrInvalidCredentials, "Token has no expiry")
+	}
+
+	if !claims.VerifyExpiresAt(now, true) {
+		return nil, errors.Wrap(ErrInvalidCredentials, "Token is expired")
+	}
+
+	if !claims.VerifyIssuer(a.issuer, true) {
+		return nil, errors.Wrap(ErrInvalidCredentials, "Token has wrong issuer")
+	}
+
+	if !claims.VerifyAudience(a.audience, true) {
+		return nil, errors.Wrap(ErrInvalidCredentials, "Token has wrong audience")
+	}
+
+	projects, err := claimProjects(claims[a.projectsClaim])
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidCredentials, err.Error())
+	}
+
+	name, _ := claims["sub"].(string)
+
+	return newPrincipal(authenticatorJWT, name, projects, []Operation{OperationRead})
+}
+
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := a.jwks.key(kid)
+	if !ok {
+		return nil, errors.Errorf("Unknown key id '%s'", kid)
+	}
+
+	return key, nil
+}
+
+// claimProjects reads the project ids of a claim, which may be a single project or a list of projects.
+func claimProjects(claim interface{}) ([]string, error) {
+	switch c := claim.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		projects := make([]string, 0, len(c))
+
+		for _, p := range c {
+			s, err := claimProject(p)
+			if err != nil {
+				return nil, err
+			}
+
+			projects = append(projects, s)
+		}
+
+		return projects, nil
+	default:
+		s, err := claimProject(c)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{s}, nil
+	}
+}
+
+func claimProject(p interface{}) (string, error) {
+	switch v := p.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return fmt.Sprintf("%.0f", v), nil
+	default:
+		return "", errors.Errorf("Invalid project '%v' in claim", p)
+	}
+}
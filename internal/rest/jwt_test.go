@@ -0,0 +1,278 @@
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/pkg/errors"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "parrot"
+	testKeyID    = "test-key"
+)
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+// newTestJWKS writes a jwks of a new rsa key to a file, and returns the jwks and the private key.
+func newTestJWKS(t *testing.T) (*JWKS, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"keys": []jsonWebKey{{
+			Kty: "RSA",
+			Kid: testKeyID,
+			Use: "sig",
+			N:   encodeBigInt(key.N),
+			E:   encodeBigInt(big.NewInt(int64(key.E))),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal jwks: %v", err)
+	}
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, b, 0o600); err != nil {
+		t.Fatalf("Failed to write jwks: %v", err)
+	}
+
+	jwks, err := NewJWKS(file)
+	if err != nil {
+		t.Fatalf("NewJWKS() error = %v", err)
+	}
+
+	return jwks, key
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	return s
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	jwks, key := newTestJWKS(t)
+
+	a, err := NewJWTAuthenticator(jwks, testIssuer, testAudience, "projects")
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator() error = %v", err)
+	}
+
+	claims := func(modify func(c jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":      "service",
+			"iss":      testIssuer,
+			"aud":      testAudience,
+			"exp":      time.Now().Add(time.Hour).Unix(),
+			"projects": []interface{}{1, "2"},
+		}
+		if modify != nil {
+			modify(c)
+		}
+
+		return c
+	}
+
+	tests := []struct {
+		name         string
+		token        string
+		wantProjects map[int]bool
+		wantErr      error
+		wantMessage  string
+	}{
+		{
+			name:         "valid",
+			token:        signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(nil)),
+			wantProjects: map[int]bool{1: true, 2: true},
+		},
+		{
+			name: "single project",
+			token: signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(func(c jwt.MapClaims) {
+				c["projects"] = 3
+			})),
+			wantProjects: map[int]bool{3: true},
+		},
+		{
+			name: "audience list",
+			token: signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(func(c jwt.MapClaims) {
+				c["aud"] = []string{"other", testAudience}
+			})),
+			wantProjects: map[int]bool{1: true, 2: true},
+		},
+		{
+			name: "expired",
+			token: signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(func(c jwt.MapClaims) {
+				c["exp"] = time.Now().Add(-time.Minute).Unix()
+			})),
+			wantErr:     ErrInvalidCredentials,
+			wantMessage: "Token is expired",
+		},
+		{
+			name: "no expiry",
+			token: signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(func(c jwt.MapClaims) {
+				delete(c, "exp")
+			})),
+			wantErr:     ErrInvalidCredentials,
+			wantMessage: "Token has no expiry",
+		},
+		{
+			name: "wrong issuer",
+			token: signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(func(c jwt.MapClaims) {
+				c["iss"] = "https://other.example.com"
+			})),
+			wantErr:     ErrInvalidCredentials,
+			wantMessage: "Token has wrong issuer",
+		},
+		{
+			name: "wrong audience",
+			token: signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(func(c jwt.MapClaims) {
+				c["aud"] = "other"
+			})),
+			wantErr:     ErrInvalidCredentials,
+			wantMessage: "Token has wrong audience",
+		},
+		{
+			name: "invalid project",
+			token: signToken(t, jwt.SigningMethodRS256, testKeyID, key, claims(func(c jwt.MapClaims) {
+				c["projects"] = []interface{}{true}
+			})),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "unknown key",
+			token:   signToken(t, jwt.SigningMethodRS256, "other-key", key, claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "symmetric signature",
+			token:   signToken(t, jwt.SigningMethodHS256, testKeyID, []byte("secret"), claims(nil)),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "api key",
+			token:   "secret",
+			wantErr: ErrNoCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := a.Authenticate(newBearerRequest(http.MethodGet, "/", tt.token))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				if !strings.Contains(err.Error(), tt.wantMessage) {
+					t.Errorf("Authenticate() error = %v, want %q", err, tt.wantMessage)
+				}
+
+				return
+			}
+
+			if p.Name != "service" || p.Authenticator != authenticatorJWT {
+				t.Errorf("Authenticate() = %s:%s, want %s:service", p.Authenticator, p.Name, authenticatorJWT)
+			}
+
+			if !reflect.DeepEqual(p.Projects, tt.wantProjects) {
+				t.Errorf("Authenticate() projects = %v, want %v", p.Projects, tt.wantProjects)
+			}
+
+			if !p.Operations[OperationRead] || len(p.Operations) != 1 {
+				t.Errorf("Authenticate() operations = %v, want read only", p.Operations)
+			}
+		})
+	}
+}
+
+func TestNewJWTAuthenticatorRequiresIssuerAndAudience(t *testing.T) {
+	jwks, _ := newTestJWKS(t)
+
+	if _, err := NewJWTAuthenticator(jwks, "", testAudience, "projects"); err == nil {
+		t.Error("NewJWTAuthenticator() expected an error without an issuer")
+	}
+
+	if _, err := NewJWTAuthenticator(jwks, testIssuer, "", "projects"); err == nil {
+		t.Error("NewJWTAuthenticator() expected an error without an audience")
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+
+	ec := jsonWebKey{Kty: "EC", Kid: "ec", Crv: "P-256", X: encodeBigInt(ecKey.X), Y: encodeBigInt(ecKey.Y)}
+
+	tests := []struct {
+		name     string
+		keys     []jsonWebKey
+		wantKeys []string
+		wantErr  bool
+	}{
+		{name: "ec key", keys: []jsonWebKey{ec}, wantKeys: []string{"ec"}},
+		{name: "encryption key", keys: []jsonWebKey{{Kty: "EC", Kid: "enc", Use: "enc", Crv: "P-256"}}, wantKeys: []string{}},
+		{name: "unsupported type", keys: []jsonWebKey{{Kty: "oct", Kid: "oct"}}, wantKeys: []string{}},
+		{name: "unsupported curve", keys: []jsonWebKey{{Kty: "EC", Kid: "ec", Crv: "P-224"}}, wantErr: true},
+		{name: "invalid modulus", keys: []jsonWebKey{{Kty: "RSA", Kid: "rsa", N: "!", E: "AQAB"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := json.Marshal(map[string]interface{}{"keys": tt.keys})
+			if err != nil {
+				t.Fatalf("Failed to marshal jwks: %v", err)
+			}
+
+			keys, err := parseJWKS(b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJWKS() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			got := []string{}
+			for kid := range keys {
+				got = append(got, kid)
+			}
+
+			if !reflect.DeepEqual(got, tt.wantKeys) {
+				t.Errorf("parseJWKS() keys = %v, want %v", got, tt.wantKeys)
+			}
+		})
+	}
+}
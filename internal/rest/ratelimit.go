@@ -0,0 +1,156 @@
+package rest
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/uniwise/parrot/internal/project"
+	"golang.org/x/time/rate"
+)
+
+const rateLimiterExpiresIn = time.Minute * 3
+
+// RateLimit is a token bucket, refilled with Rate tokens per second and holding at most Burst tokens.
+type RateLimit struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+// retryAfter is the time it takes the bucket to refill a single token.
+func (r RateLimit) retryAfter() time.Duration {
+	if r.Rate <= 0 {
+		return time.Minute
+	}
+
+	return time.Duration(math.Ceil(1/r.Rate)) * time.Second
+}
+
+type rateLimitStore struct {
+	limit RateLimit
+	store *middleware.RateLimiterMemoryStore
+}
+
+func newRateLimitStore(limit RateLimit) *rateLimitStore {
+	return &rateLimitStore{
+		limit: limit,
+		store: middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(limit.Rate),
+			Burst:     limit.Burst,
+			ExpiresIn: rateLimiterExpiresIn,
+		}),
+	}
+}
+
+// RateLimiter limits the request rate of each client, identified by its principal or its ip.
+// Clients have separate budgets for requests, and for requests which trigger a fetch from poeditor.
+type RateLimiter struct {
+	logger *logrus.Entry
+	mutex  *sync.RWMutex
+	hits   *rateLimitStore
+	misses *rateLimitStore
+}
+
+func NewRateLimiter(l *logrus.Entry, hits, misses RateLimit) *RateLimiter {
+	r := &RateLimiter{
+		logger: l,
+		mutex:  &sync.RWMutex{},
+	}
+
+	r.SetLimits(hits, misses)
+
+	return r
+}
+
+// SetLimits replaces the budgets of the rate limiter. Clients start over with a full budget,
+// for the budgets which changed. The budgets which did not change are kept as they are.
+func (r *RateLimiter) SetLimits(hits, misses RateLimit) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.hits == nil || r.hits.limit != hits {
+		r.hits = newRateLimitStore(hits)
+	}
+
+	if r.misses == nil || r.misses.limit != misses {
+		r.misses = newRateLimitStore(misses)
+	}
+}
+
+// Allow consumes a token of the request budget of the client.
+func (r *RateLimiter) Allow(identifier string) (bool, error) {
+	hits, _ := r.stores()
+
+	return hits.store.Allow(identifier)
+}
+
+// Middleware rejects requests of clients which have exhausted their request budget,
+// and makes the service reject cache misses of clients which have exhausted their fetch budget.
+func (r *RateLimiter) Middleware() echo.MiddlewareFunc {
+	limiter := middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store:               r,
+		IdentifierExtractor: rateLimitIdentifier,
+		DenyHandler: func(ctx echo.Context, identifier string, err error) error {
+			r.logger.WithField("client", identifier).Debug("Rate limited request")
+
+			hits, _ := r.stores()
+
+			return tooManyRequests(ctx, hits.limit.retryAfter())
+		},
+	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return limiter(func(ctx echo.Context) error {
+			identifier, _ := rateLimitIdentifier(ctx)
+
+			req := ctx.Request()
+			ctx.SetRequest(req.WithContext(project.WithUpstreamGuard(req.Context(), func() error {
+				_, misses := r.stores()
+
+				ok, err := misses.store.Allow(identifier)
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					r.logger.WithField("client", identifier).Debug("Rate limited cache miss")
+
+					return &project.ErrRateLimited{
+						RetryAfter: misses.limit.retryAfter(),
+					}
+				}
+
+				return nil
+			})))
+
+			return next(ctx)
+		})
+	}
+}
+
+func (r *RateLimiter) stores() (hits, misses *rateLimitStore) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.hits, r.misses
+}
+
+// rateLimitIdentifier identifies the client of a request by its principal, if authenticated, or by its ip.
+// Principals are identified by their authenticator too, as a jwt subject may have the name of an api key.
+func rateLimitIdentifier(ctx echo.Context) (string, error) {
+	if p, ok := GetPrincipal(ctx); ok && p.Name != "" {
+		return "principal:" + p.Authenticator + ":" + p.Name, nil
+	}
+
+	return "ip:" + ctx.RealIP(), nil
+}
+
+func tooManyRequests(ctx echo.Context, retryAfter time.Duration) error {
+	ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+
+	return echo.ErrTooManyRequests
+}
@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TestRateLimitRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit RateLimit
+		want  time.Duration
+	}{
+		{name: "one per second", limit: RateLimit{Rate: 1}, want: time.Second},
+		{name: "faster than a second", limit: RateLimit{Rate: 10}, want: time.Second},
+		{name: "slower than a second", limit: RateLimit{Rate: 0.25}, want: time.Second * 4},
+		{name: "no refill", limit: RateLimit{Rate: 0}, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.limit.retryAfter(); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitIdentifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *Principal
+		want      string
+	}{
+		{name: "anonymous", want: "ip:192.0.2.1"},
+		{name: "api key", principal: &Principal{Authenticator: authenticatorAPIKey, Name: "web"}, want: "principal:apikey:web"},
+		{name: "jwt", principal: &Principal{Authenticator: authenticatorJWT, Name: "web"}, want: "principal:jwt:web"},
+		{name: "jwt without subject", principal: &Principal{Authenticator: authenticatorJWT}, want: "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+
+			ctx := echo.New().NewContext(req, httptest.NewRecorder())
+			if tt.principal != nil {
+				ctx.Set(principalContextKey, tt.principal)
+			}
+
+			got, err := rateLimitIdentifier(ctx)
+			if err != nil {
+				t.Fatalf("rateLimitIdentifier() error = %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("rateLimitIdentifier() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(testLogger(), RateLimit{Rate: 0.5, Burst: 1}, RateLimit{Rate: 1, Burst: 1})
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(ctx echo.Context) error {
+			if name := ctx.Request().Header.Get("X-Principal"); name != "" {
+				ctx.Set(principalContextKey, &Principal{Authenticator: ctx.Request().Header.Get("X-Authenticator"), Name: name})
+			}
+
+			return next(ctx)
+		}
+	})
+	e.Use(limiter.Middleware())
+	e.GET("/", func(ctx echo.Context) error {
+		return ctx.NoContent(http.StatusOK)
+	})
+
+	request := func(ip, authenticator, name string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set("X-Authenticator", authenticator)
+		req.Header.Set("X-Principal", name)
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	tests := []struct {
+		name          string
+		ip            string
+		authenticator string
+		principal     string
+		want          int
+	}{
+		{name: "first request", ip: "192.0.2.1", want: http.StatusOK},
+		{name: "exhausted budget", ip: "192.0.2.1", want: http.StatusTooManyRequests},
+		{name: "other ip", ip: "192.0.2.2", want: http.StatusOK},
+		{name: "api key", ip: "192.0.2.1", authenticator: authenticatorAPIKey, principal: "web", want: http.StatusOK},
+		{name: "api key exhausted from other ip", ip: "192.0.2.3", authenticator: authenticatorAPIKey, principal: "web", want: http.StatusTooManyRequests},
+		{name: "jwt of the same name", ip: "192.0.2.3", authenticator: authenticatorJWT, principal: "web", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := request(tt.ip, tt.authenticator, tt.principal)
+		if rec.Code != tt.want {
+			t.Fatalf("%s: status = %d, want %d", tt.name, rec.Code, tt.want)
+		}
+
+		if tt.want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "2" {
+			t.Errorf("%s: Retry-After = %q, want %q", tt.name, rec.Header().Get("Retry-After"), "2")
+		}
+	}
+}
+
+func TestRateLimiterSetLimits(t *testing.T) {
+	hits := RateLimit{Rate: 1, Burst: 1}
+	misses := RateLimit{Rate: 1, Burst: 1}
+
+	r := NewRateLimiter(testLogger(), hits, misses)
+
+	if ok, _ := r.Allow("client"); !ok {
+		t.Fatal("Allow() rejected the first request")
+	}
+
+	// Unchanged budgets are kept, such that reloading the configuration does not reset them
+	r.SetLimits(hits, RateLimit{Rate: 2, Burst: 2})
+
+	if ok, _ := r.Allow("client"); ok {
+		t.Error("Allow() accepted a request of an exhausted budget, which did not change")
+	}
+
+	r.SetLimits(RateLimit{Rate: 1, Burst: 2}, misses)
+
+	if ok, _ := r.Allow("client"); !ok {
+		t.Error("Allow() rejected a request of a budget, which changed")
+	}
+}
@@ -3,6 +3,7 @@ package rest
 import (
 	"context"
 	"fmt"
+	"net"
 
 	gosundheit "github.com/AppsFlyer/go-sundheit"
 	healthhttp "github.com/AppsFlyer/go-sundheit/http"
@@ -23,12 +24,17 @@ type Server struct {
 	Echo *echo.Echo
 }
 
-func NewServer(l *logrus.Entry, projectService project.Service, enablePrometheus bool) (*Server, error) {
+// NewServer creates the parrot http server.
+// Access to the api is only restricted when access control is given, and only rate limited when a rate limiter is given.
+func NewServer(l *logrus.Entry, projectService project.Service, enablePrometheus bool, access *AccessControl, limiter *RateLimiter) (*Server, error) {
 	e := echo.New()
 
 	e.HideBanner = true
 	e.HidePort = true
 	e.Validator = NewValidator()
+	// Headers such as X-Forwarded-For can be set to anything by the client, so they are only trusted when
+	// the ip extractor is replaced with one trusting the proxies in front of parrot
+	e.IPExtractor = echo.ExtractIPDirect()
 
 	e.Use(middleware.Recover())
 	e.Use(middleware.RequestID())
@@ -36,7 +42,16 @@ func NewServer(l *logrus.Entry, projectService project.Service, enablePrometheus
 		Level: gzipCompressionLevel,
 	}))
 
-	v1.Register(e, l, projectService, enablePrometheus)
+	var middlewares []echo.MiddlewareFunc
+	if access != nil {
+		middlewares = append(middlewares, access.Middleware())
+	}
+
+	if limiter != nil {
+		middlewares = append(middlewares, limiter.Middleware())
+	}
+
+	v1.Register(e, l, projectService, enablePrometheus, middlewares...)
 
 	h := gosundheit.New()
 
@@ -51,6 +66,51 @@ func NewServer(l *logrus.Entry, projectService project.Service, enablePrometheus
 	}, nil
 }
 
+// IPExtractor returns the extractor of client ips, which trusts the X-Forwarded-For header of requests from the proxies.
+// The proxies are given as ip addresses or cidr ranges. Without proxies, clients are identified by the address they connect from.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, proxy := range trustedProxies {
+		ipRange, err := parseIPRange(proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// parseIPRange parses a cidr range, or a single ip address as a range of one address.
+func parseIPRange(s string) (*net.IPNet, error) {
+	if _, ipRange, err := net.ParseCIDR(s); err == nil {
+		return ipRange, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, errors.Errorf("Invalid proxy address '%s'", s)
+	}
+
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 8 * net.IPv4len
+	}
+
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
+
 func (s *Server) Start(port int) error {
 	return s.Echo.Start(fmt.Sprintf(":%d", port))
 }
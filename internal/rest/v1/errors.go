@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	"github.com/uniwise/parrot/internal/project"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// serviceError maps an error from the project service to an http error.
+// Errors that do not map to a client error are logged with the message.
+func serviceError(ctx echo.Context, l *logrus.Entry, err error, msg string) error {
+	if errors.Is(err, context.Canceled) {
+		return echo.NewHTTPError(499, "client closed request")
+	}
+
+	if errors.Is(err, project.ErrUpstreamSaturated) || errors.Is(err, poedit.ErrCircuitOpen) {
+		l.WithError(err).Warn(msg)
+
+		return echo.ErrServiceUnavailable
+	}
+
+	if errors.Is(err, poedit.ErrInvalidToken) {
+		l.WithError(err).Error(msg)
+
+		return echo.ErrBadGateway
+	}
+
+	if errors.Is(err, poedit.ErrUploadThrottled) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	}
+
+	switch e := err.(type) {
+	case *poedit.ErrProjectPermissionDenied, *poedit.ErrInvalidLanguage, *project.ErrNoAPIToken:
+		return echo.ErrBadRequest
+	case *poedit.ErrLanguageNotFound, *poedit.ErrProjectNotFound:
+		return echo.ErrNotFound
+	case *project.ErrRateLimited:
+		ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds())))
+
+		return echo.NewHTTPError(http.StatusTooManyRequests, e.Error())
+	case *poedit.ErrRateLimited:
+		l.WithError(err).Warn(msg)
+
+		if e.RetryAfter > 0 {
+			ctx.Response().Header().Set("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds())))
+		}
+
+		return echo.ErrTooManyRequests
+	case *poedit.ErrAPI:
+		l.WithError(err).Error(msg)
+
+		return echo.ErrBadGateway
+	case *poedit.ErrUnavailable:
+		l.WithError(err).Warn(msg)
+
+		return echo.ErrServiceUnavailable
+	default:
+		l.WithError(err).Error(msg)
+
+		return echo.ErrInternalServerError
+	}
+}
@@ -2,10 +2,10 @@ package v1
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
@@ -56,21 +56,8 @@ func (h *Handlers) getProjectLanguage(ctx echo.Context, l *logrus.Entry) error {
 		req.Language,
 		format,
 	)
-	if errors.Is(err, context.Canceled) {
-		return echo.NewHTTPError(499, "client closed request")
-	}
-
 	if err != nil {
-		switch err.(type) {
-		case *poedit.ErrProjectPermissionDenied:
-			return echo.ErrBadRequest
-		case *poedit.ErrLanguageNotFound:
-			return echo.ErrNotFound
-		default:
-			l.WithError(err).Error("Error retrieving translation")
-
-			return echo.ErrInternalServerError
-		}
+		return serviceError(ctx, l, err, "Error retrieving translation")
 	}
 
 	if ctx.Request() == nil {
@@ -90,3 +77,114 @@ func (h *Handlers) getProjectLanguage(ctx echo.Context, l *logrus.Entry) error {
 
 	return ctx.Stream(http.StatusOK, contentMeta.Type, bytes.NewReader(trans.Data))
 }
+
+type getProjectRequest struct {
+	Project int `param:"project" validate:"required"`
+}
+
+type getProjectResponse struct {
+	ID                int        `json:"id"`
+	Name              string     `json:"name"`
+	ReferenceLanguage string     `json:"referenceLanguage"`
+	Terms             int64      `json:"terms"`
+	Updated           *time.Time `json:"updated"`
+}
+
+func (h *Handlers) getProject(ctx echo.Context, l *logrus.Entry) error {
+	req := new(getProjectRequest)
+	if err := ctx.Bind(req); err != nil {
+		l.WithError(err).Error("Error binding request")
+
+		return echo.ErrBadRequest
+	}
+
+	l = l.WithField("project", req.Project)
+
+	if err := ctx.Validate(req); err != nil {
+		l.WithError(err).Error("Error validating request")
+
+		return echo.ErrBadRequest
+	}
+
+	p, err := h.ProjectService.GetProject(ctx.Request().Context(), req.Project)
+	if err != nil {
+		return serviceError(ctx, l, err, "Error retrieving project")
+	}
+
+	res := getProjectResponse{
+		ID:                p.ID,
+		Name:              p.Name,
+		ReferenceLanguage: p.ReferenceLanguage,
+		Terms:             p.Terms,
+	}
+
+	if !p.Updated.IsZero() {
+		res.Updated = &p.Updated
+	}
+
+	ctx.Response().Header().Add("Cache-Control", fmt.Sprintf("max-age=%.0f", p.TTL.Seconds()))
+
+	return ctx.JSON(http.StatusOK, res)
+}
+
+type purgeProjectRequest struct {
+	Project int `param:"project" validate:"required"`
+}
+
+func (h *Handlers) purgeProject(ctx echo.Context, l *logrus.Entry) error {
+	req := new(purgeProjectRequest)
+	if err := ctx.Bind(req); err != nil {
+		l.WithError(err).Error("Error binding request")
+
+		return echo.ErrBadRequest
+	}
+
+	l = l.WithField("project", req.Project)
+
+	if err := ctx.Validate(req); err != nil {
+		l.WithError(err).Error("Error validating request")
+
+		return echo.ErrBadRequest
+	}
+
+	if err := h.ProjectService.PurgeProject(ctx.Request().Context(), req.Project); err != nil {
+		l.WithError(err).Error("Error purging project")
+
+		return echo.ErrInternalServerError
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
+
+type purgeProjectLanguageRequest struct {
+	Project  int    `param:"project" validate:"required"`
+	Language string `param:"language" validate:"required,languageCode"`
+}
+
+func (h *Handlers) purgeProjectLanguage(ctx echo.Context, l *logrus.Entry) error {
+	req := new(purgeProjectLanguageRequest)
+	if err := ctx.Bind(req); err != nil {
+		l.WithError(err).Error("Error binding request")
+
+		return echo.ErrBadRequest
+	}
+
+	l = l.WithFields(logrus.Fields{
+		"project":  req.Project,
+		"language": req.Language,
+	})
+
+	if err := ctx.Validate(req); err != nil {
+		l.WithError(err).Error("Error validating request")
+
+		return echo.ErrBadRequest
+	}
+
+	if err := h.ProjectService.PurgeTranslation(ctx.Request().Context(), req.Project, req.Language); err != nil {
+		l.WithError(err).Error("Error purging translation")
+
+		return echo.ErrInternalServerError
+	}
+
+	return ctx.NoContent(http.StatusOK)
+}
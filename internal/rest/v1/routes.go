@@ -13,7 +13,7 @@ type Handlers struct {
 
 type HandlerFunction func(ctx echo.Context, l *logrus.Entry) error
 
-func Register(e *echo.Echo, l *logrus.Entry, projectService project.Service, enablePrometheus bool) {
+func Register(e *echo.Echo, l *logrus.Entry, projectService project.Service, enablePrometheus bool, middlewares ...echo.MiddlewareFunc) {
 	h := &Handlers{
 		ProjectService: projectService,
 	}
@@ -29,7 +29,12 @@ func Register(e *echo.Echo, l *logrus.Entry, projectService project.Service, ena
 		g.Use(prom)
 	}
 
+	g.Use(middlewares...)
+
+	g.GET("/project/:project", wrap(h.getProject, l))
 	g.GET("/project/:project/language/:language", wrap(h.getProjectLanguage, l))
+	g.POST("/project/:project/purge", wrap(h.purgeProject, l))
+	g.POST("/project/:project/language/:language/purge", wrap(h.purgeProjectLanguage, l))
 }
 
 func wrap(fn HandlerFunction, logger *logrus.Entry) echo.HandlerFunc {
@@ -0,0 +1,270 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type translationKey struct {
+	projectID    int
+	languageCode string
+	format       string
+}
+
+// Translation is a translation of a loaded snapshot.
+type Translation struct {
+	Language
+	ProjectID    int
+	LanguageCode string
+	Format       string
+	// Exported is the time the translation was exported from poeditor.
+	Exported time.Time
+	Data     []byte
+}
+
+// Snapshot is a set of exports, loaded into memory from a directory or a tarball.
+type Snapshot struct {
+	translations map[translationKey]*Translation
+	// projects are the latest exported manifests of the projects
+	projects map[int]*Manifest
+}
+
+// Load loads every export below a directory, or in a tar or tar.gz file, identified by their manifests.
+// The files of the exports are loaded into memory, and their checksums verified against the manifests.
+func Load(snapshotPath string) (*Snapshot, error) {
+	info, err := os.Stat(snapshotPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to open snapshot '%s'", snapshotPath)
+	}
+
+	var manifests []string
+	var read func(name string) ([]byte, error)
+
+	if info.IsDir() {
+		manifests, err = findManifests(snapshotPath)
+		read = func(name string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(snapshotPath, filepath.FromSlash(name)))
+		}
+	} else {
+		var files map[string][]byte
+		files, err = readTarball(snapshotPath)
+
+		for name := range files {
+			if path.Base(name) == ManifestFile {
+				manifests = append(manifests, name)
+			}
+		}
+
+		read = func(name string) ([]byte, error) {
+			b, ok := files[name]
+			if !ok {
+				return nil, os.ErrNotExist
+			}
+
+			return b, nil
+		}
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read snapshot '%s'", snapshotPath)
+	}
+
+	if len(manifests) == 0 {
+		return nil, errors.Errorf("Snapshot '%s' contains no %s", snapshotPath, ManifestFile)
+	}
+
+	sort.Strings(manifests)
+
+	s := &Snapshot{
+		translations: map[translationKey]*Translation{},
+		projects:     map[int]*Manifest{},
+	}
+
+	for _, name := range manifests {
+		if err := s.load(name, read); err != nil {
+			return nil, errors.Wrapf(err, "Failed to load snapshot '%s'", snapshotPath)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Snapshot) load(manifestName string, read func(name string) ([]byte, error)) error {
+	b, err := read(manifestName)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to read %s", manifestName)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return errors.Wrapf(err, "Failed to parse %s", manifestName)
+	}
+
+	if err := manifest.validate(); err != nil {
+		return errors.Wrapf(err, "Invalid %s", manifestName)
+	}
+
+	for code, l := range manifest.Languages {
+		name := path.Join(path.Dir(manifestName), l.File)
+
+		data, err := read(name)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read %s", name)
+		}
+
+		sum := md5.Sum(data)
+		if hex.EncodeToString(sum[:]) != l.Checksum {
+			return errors.Errorf("Checksum of %s does not match %s", name, manifestName)
+		}
+
+		key := translationKey{projectID: manifest.ProjectID, languageCode: code, format: manifest.Format}
+		if _, ok := s.translations[key]; ok {
+			return errors.Errorf("Language %s format %s of project %d is exported more than once", code, manifest.Format, manifest.ProjectID)
+		}
+
+		s.translations[key] = &Translation{
+			Language:     l,
+			ProjectID:    manifest.ProjectID,
+			LanguageCode: code,
+			Format:       manifest.Format,
+			Exported:     manifest.Exported,
+			Data:         data,
+		}
+	}
+
+	if latest, ok := s.projects[manifest.ProjectID]; !ok || manifest.Exported.After(latest.Exported) {
+		s.projects[manifest.ProjectID] = &manifest
+	}
+
+	return nil
+}
+
+// Translation returns a translation of the snapshot.
+func (s *Snapshot) Translation(projectID int, languageCode, format string) (*Translation, bool) {
+	t, ok := s.translations[translationKey{projectID: projectID, languageCode: languageCode, format: format}]
+
+	return t, ok
+}
+
+// HasProject reports whether the snapshot contains translations of a project.
+func (s *Snapshot) HasProject(projectID int) bool {
+	_, ok := s.projects[projectID]
+
+	return ok
+}
+
+// Manifest returns the latest exported manifest of a project in the snapshot.
+func (s *Snapshot) Manifest(projectID int) (*Manifest, bool) {
+	m, ok := s.projects[projectID]
+
+	return m, ok
+}
+
+// Translations returns every translation of the snapshot, sorted by project, language and format.
+func (s *Snapshot) Translations() []*Translation {
+	translations := make([]*Translation, 0, len(s.translations))
+	for _, t := range s.translations {
+		translations = append(translations, t)
+	}
+
+	sort.Slice(translations, func(i, j int) bool {
+		a, b := translations[i], translations[j]
+		if a.ProjectID != b.ProjectID {
+			return a.ProjectID < b.ProjectID
+		}
+
+		if a.LanguageCode != b.LanguageCode {
+			return a.LanguageCode < b.LanguageCode
+		}
+
+		return a.Format < b.Format
+	})
+
+	return translations
+}
+
+// findManifests returns the slash separated paths of the manifests below a directory, relative to it.
+func findManifests(dir string) ([]string, error) {
+	var manifests []string
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || info.Name() != ManifestFile {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		manifests = append(manifests, filepath.ToSlash(rel))
+
+		return nil
+	})
+
+	return manifests, err
+}
+
+// readTarball reads the regular files of a tar file, which may be gzip compressed, keyed by their cleaned slash separated paths.
+func readTarball(name string) (map[string][]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		r = gz
+	}
+
+	files := map[string][]byte{}
+	tr := tar.NewReader(r)
+
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		files[path.Clean(header.Name)] = b
+	}
+}
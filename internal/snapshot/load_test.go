@@ -0,0 +1,252 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeExport writes the translations by language code and their manifest to a directory below the snapshot directory.
+// A checksum of the manifest is kept, if the manifest already has a language of the code.
+func writeExport(t *testing.T, snapshotDir, dir string, manifest Manifest, translations map[string]string) {
+	t.Helper()
+
+	exportDir := filepath.Join(snapshotDir, dir)
+	if err := os.MkdirAll(exportDir, 0o755); err != nil {
+		t.Fatalf("Failed to create export directory: %v", err)
+	}
+
+	if manifest.Languages == nil {
+		manifest.Languages = map[string]Language{}
+	}
+
+	for code, data := range translations {
+		l, ok := manifest.Languages[code]
+		if !ok {
+			sum := md5.Sum([]byte(data))
+			l = Language{File: code + ".json", Checksum: hex.EncodeToString(sum[:])}
+		}
+
+		if err := os.WriteFile(filepath.Join(exportDir, code+".json"), []byte(data), 0o644); err != nil {
+			t.Fatalf("Failed to write translation: %v", err)
+		}
+
+		manifest.Languages[code] = l
+	}
+
+	if err := WriteManifest(exportDir, &manifest); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+}
+
+// writeTarball writes the files below a directory to a tar file, gzip compressed if the name ends with .gz.
+func writeTarball(t *testing.T, dir, name string) {
+	t.Helper()
+
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("Failed to create tarball: %v", err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	if strings.HasSuffix(name, ".gz") {
+		gz := gzip.NewWriter(f)
+		defer gz.Close()
+
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     "./" + filepath.ToSlash(rel),
+			Mode:     0o644,
+			Size:     int64(len(b)),
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return err
+		}
+
+		_, err = tw.Write(b)
+
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Failed to write tarball: %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	older := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	dir := t.TempDir()
+	writeExport(t, dir, "1/po", Manifest{ProjectID: 1, Name: "Old", Format: "po", Exported: older}, map[string]string{"da": "msgid \"hello\"\nmsgstr \"Hej\"\n"})
+	writeExport(t, dir, "1/json", Manifest{ProjectID: 1, Name: "New", Format: testFormat, Exported: newer}, map[string]string{"da": `{"hello":"Hej"}`, "de": `{"hello":"Hallo"}`})
+	writeExport(t, dir, "2", Manifest{ProjectID: 2, Format: testFormat, Exported: older}, map[string]string{"en": `{"hello":"Hello"}`})
+
+	tarball := filepath.Join(t.TempDir(), "snapshot.tar")
+	writeTarball(t, dir, tarball)
+
+	gzipped := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	writeTarball(t, dir, gzipped)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{name: "directory", path: dir},
+		{name: "tarball", path: tarball},
+		{name: "gzipped tarball", path: gzipped},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Load(tt.path)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			got := []string{}
+			for _, tr := range s.Translations() {
+				got = append(got, strings.Join([]string{tr.LanguageCode, tr.Format, string(tr.Data)}, " "))
+			}
+
+			want := []string{
+				"da key_value_json {\"hello\":\"Hej\"}",
+				"da po msgid \"hello\"\nmsgstr \"Hej\"\n",
+				"de key_value_json {\"hello\":\"Hallo\"}",
+				"en key_value_json {\"hello\":\"Hello\"}",
+			}
+
+			if strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("Translations() = %q, want %q", got, want)
+			}
+
+			tr, ok := s.Translation(1, "da", "po")
+			if !ok || !tr.Exported.Equal(older) {
+				t.Errorf("Translation() = %+v, want the translation exported at %v", tr, older)
+			}
+
+			m, ok := s.Manifest(1)
+			if !ok || m.Name != "New" {
+				t.Errorf("Manifest() = %+v, want the latest exported manifest", m)
+			}
+
+			if !s.HasProject(2) || s.HasProject(3) {
+				t.Error("HasProject() does not match the projects of the snapshot")
+			}
+		})
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, dir string)
+	}{
+		{
+			name:  "no manifest",
+			write: func(t *testing.T, dir string) {},
+		},
+		{
+			name: "checksum mismatch",
+			write: func(t *testing.T, dir string) {
+				writeExport(t, dir, "1", Manifest{
+					ProjectID: 1,
+					Format:    testFormat,
+					Languages: map[string]Language{"da": {File: "da.json", Checksum: "0123456789abcdef0123456789abcdef"}},
+				}, map[string]string{"da": `{"hello":"Hej"}`})
+			},
+		},
+		{
+			name: "missing file",
+			write: func(t *testing.T, dir string) {
+				writeExport(t, dir, "1", Manifest{
+					ProjectID: 1,
+					Format:    testFormat,
+					Languages: map[string]Language{"da": {File: "missing.json"}},
+				}, nil)
+			},
+		},
+		{
+			name: "file outside the snapshot",
+			write: func(t *testing.T, dir string) {
+				writeExport(t, dir, "1", Manifest{ProjectID: 1, Format: testFormat}, map[string]string{"da": `{"hello":"Hej"}`})
+				writeExport(t, dir, "2", Manifest{
+					ProjectID: 2,
+					Format:    testFormat,
+					Languages: map[string]Language{"da": {File: "../1/da.json"}},
+				}, nil)
+			},
+		},
+		{
+			name: "absolute file",
+			write: func(t *testing.T, dir string) {
+				writeExport(t, dir, "1", Manifest{
+					ProjectID: 1,
+					Format:    testFormat,
+					Languages: map[string]Language{"da": {File: "/etc/passwd"}},
+				}, nil)
+			},
+		},
+		{
+			name: "language exported twice",
+			write: func(t *testing.T, dir string) {
+				writeExport(t, dir, "a", Manifest{ProjectID: 1, Format: testFormat}, map[string]string{"da": `{"hello":"Hej"}`})
+				writeExport(t, dir, "b", Manifest{ProjectID: 1, Format: testFormat}, map[string]string{"da": `{"hello":"Hej"}`})
+			},
+		},
+		{
+			name: "invalid manifest",
+			write: func(t *testing.T, dir string) {
+				if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte("{"), 0o644); err != nil {
+					t.Fatalf("Failed to write manifest: %v", err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.write(t, dir)
+
+			if s, err := Load(dir); err == nil {
+				t.Errorf("Load() = %+v, want an error", s.Translations())
+			}
+		})
+	}
+}
+
+func TestLoadMissingSnapshot(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.tar.gz")); err == nil {
+		t.Error("Load() expected an error for a missing snapshot")
+	}
+}
@@ -0,0 +1,45 @@
+package snapshot
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/internal/cache"
+)
+
+// Seed stores the translations of a snapshot in a cache, unless the cache holds the same or a newer version of them.
+// The translations are cached as of the time they were exported, such that they expire as they would have if cached then,
+// and translations older than the ttl of the cache are not stored. It returns the number of translations stored.
+func Seed(ctx context.Context, c cache.Cache, s *Snapshot) (int, error) {
+	seeded := 0
+
+	for _, t := range s.Translations() {
+		item, err := c.GetTranslation(ctx, t.ProjectID, t.LanguageCode, t.Format)
+		if err != nil && !errors.Is(err, cache.ErrCacheMiss) {
+			return seeded, err
+		}
+
+		// The update time is unknown for items cached before it was recorded, so the item is also kept if cached after the export
+		if err == nil && (item.Checksum == t.Checksum || item.Updated.After(t.Updated) || item.CreatedAt.After(t.Exported)) {
+			continue
+		}
+
+		err = c.RestoreTranslation(ctx, t.ProjectID, t.LanguageCode, t.Format, &cache.CacheItem{
+			CreatedAt: t.Exported,
+			Updated:   t.Updated,
+			Checksum:  t.Checksum,
+			Data:      t.Data,
+		})
+		if errors.Is(err, cache.ErrExpired) {
+			continue
+		}
+
+		if err != nil {
+			return seeded, errors.Wrapf(err, "Failed to seed language %s format %s of project %d", t.LanguageCode, t.Format, t.ProjectID)
+		}
+
+		seeded++
+	}
+
+	return seeded, nil
+}
@@ -0,0 +1,163 @@
+package snapshot
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/uniwise/parrot/internal/cache"
+)
+
+const testFormat = "key_value_json"
+
+// writeSnapshot writes a snapshot of project 1 with the translations by language code, exported at the time.
+func writeSnapshot(t *testing.T, exported, updated time.Time, translations map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	manifest := &Manifest{
+		ProjectID: 1,
+		Format:    testFormat,
+		Exported:  exported,
+		Languages: map[string]Language{},
+	}
+
+	for code, data := range translations {
+		file := code + ".json"
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(data), 0o644); err != nil {
+			t.Fatalf("Failed to write translation: %v", err)
+		}
+
+		sum := md5.Sum([]byte(data))
+		manifest.Languages[code] = Language{
+			File:     file,
+			Checksum: hex.EncodeToString(sum[:]),
+			Size:     int64(len(data)),
+			Updated:  updated,
+		}
+	}
+
+	if err := WriteManifest(dir, manifest); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+
+	return dir
+}
+
+func TestSeed(t *testing.T) {
+	exported := time.Now().Add(-time.Hour).Truncate(time.Second)
+	updated := exported.Add(-time.Hour)
+
+	tests := []struct {
+		name string
+		// cached is the translation in the cache before seeding, if any
+		cached   string
+		cachedAt time.Time
+		updated  time.Time
+		exported time.Time
+		seeded   int
+		expected string
+	}{
+		{
+			name:     "cold cache",
+			exported: exported,
+			seeded:   1,
+			expected: `{"hello":"Hej"}`,
+		},
+		{
+			name:     "snapshot older than the ttl",
+			exported: time.Now().Add(-time.Hour * 48),
+			seeded:   0,
+		},
+		{
+			name:     "older cached translation",
+			cached:   `{"hello":"Hi"}`,
+			cachedAt: exported.Add(-time.Minute),
+			updated:  updated.Add(-time.Minute),
+			exported: exported,
+			seeded:   1,
+			expected: `{"hello":"Hej"}`,
+		},
+		{
+			name:     "newer cached translation",
+			cached:   `{"hello":"Hi"}`,
+			cachedAt: exported.Add(-time.Minute),
+			updated:  updated.Add(time.Minute),
+			exported: exported,
+			seeded:   0,
+			expected: `{"hello":"Hi"}`,
+		},
+		{
+			name:     "translation cached after the export without update time",
+			cached:   `{"hello":"Hi"}`,
+			cachedAt: exported.Add(time.Minute),
+			exported: exported,
+			seeded:   0,
+			expected: `{"hello":"Hi"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			c, err := cache.NewFilesystemCache(t.TempDir(), time.Hour*24)
+			if err != nil {
+				t.Fatalf("Failed to create cache: %v", err)
+			}
+
+			if tt.cached != "" {
+				sum := md5.Sum([]byte(tt.cached))
+				if err := c.RestoreTranslation(ctx, 1, "da", testFormat, &cache.CacheItem{
+					CreatedAt: tt.cachedAt,
+					Updated:   tt.updated,
+					Checksum:  hex.EncodeToString(sum[:]),
+					Data:      []byte(tt.cached),
+				}); err != nil {
+					t.Fatalf("Failed to cache translation: %v", err)
+				}
+			}
+
+			s, err := Load(writeSnapshot(t, tt.exported, updated, map[string]string{"da": `{"hello":"Hej"}`}))
+			if err != nil {
+				t.Fatalf("Failed to load snapshot: %v", err)
+			}
+
+			seeded, err := Seed(ctx, c, s)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if seeded != tt.seeded {
+				t.Errorf("Expected %d seeded translations, got %d", tt.seeded, seeded)
+			}
+
+			item, err := c.GetTranslation(ctx, 1, "da", testFormat)
+			if tt.expected == "" {
+				if err == nil {
+					t.Errorf("Expected nothing to be cached, got %s", item.Data)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected a cached translation: %v", err)
+			}
+
+			if string(item.Data) != tt.expected {
+				t.Errorf("Expected %s to be cached, got %s", tt.expected, item.Data)
+			}
+
+			if seeded > 0 && !item.CreatedAt.Equal(tt.exported) {
+				t.Errorf("Expected the seeded translation to be cached as of the export at %s, got %s", tt.exported, item.CreatedAt)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+
+	gosundheit "github.com/AppsFlyer/go-sundheit"
+	"github.com/uniwise/parrot/internal/project"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// Service serves the translations of a snapshot, in place of poeditor and the cache.
+type Service struct {
+	snapshot *Snapshot
+	ttl      time.Duration
+}
+
+// NewService returns a service serving the translations of a snapshot, with the ttl as their max age.
+func NewService(s *Snapshot, ttl time.Duration) *Service {
+	return &Service{
+		snapshot: s,
+		ttl:      ttl,
+	}
+}
+
+func (s *Service) GetTranslation(ctx context.Context, projectID int, languageCode, format string) (*project.Translation, error) {
+	t, ok := s.snapshot.Translation(projectID, languageCode, format)
+	if !ok {
+		if !s.snapshot.HasProject(projectID) {
+			return nil, &poedit.ErrProjectNotFound{ProjectID: projectID}
+		}
+
+		return nil, &poedit.ErrLanguageNotFound{ProjectID: projectID, LanguageCode: languageCode}
+	}
+
+	return &project.Translation{
+		TTL:      s.ttl,
+		Checksum: t.Checksum,
+		Data:     t.Data,
+	}, nil
+}
+
+// GetProject returns the project metadata of the latest export of the project in the snapshot,
+// with the time of the latest change of any of its exported languages.
+func (s *Service) GetProject(ctx context.Context, projectID int) (*project.Project, error) {
+	m, ok := s.snapshot.Manifest(projectID)
+	if !ok {
+		return nil, &poedit.ErrProjectNotFound{ProjectID: projectID}
+	}
+
+	p := &project.Project{
+		ID:                projectID,
+		Name:              m.Name,
+		ReferenceLanguage: m.ReferenceLanguage,
+		Terms:             m.Terms,
+		TTL:               s.ttl,
+	}
+
+	for _, t := range s.snapshot.Translations() {
+		if t.ProjectID == projectID && t.Updated.After(p.Updated) {
+			p.Updated = t.Updated
+		}
+	}
+
+	return p, nil
+}
+
+// PurgeTranslation does nothing, as nothing is cached when serving a snapshot.
+func (s *Service) PurgeTranslation(ctx context.Context, projectID int, languageCode string) error {
+	return nil
+}
+
+// PurgeProject does nothing, as nothing is cached when serving a snapshot.
+func (s *Service) PurgeProject(ctx context.Context, projectID int) error {
+	return nil
+}
+
+// RegisterChecks registers no checks, as a snapshot depends on nothing which could become unhealthy.
+func (s *Service) RegisterChecks(h gosundheit.Health) error {
+	return nil
+}
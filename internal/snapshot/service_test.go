@@ -0,0 +1,55 @@
+package snapshot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/internal/outbound"
+	"github.com/uniwise/parrot/pkg/poedit"
+	"github.com/uniwise/parrot/pkg/poedit/poedittest"
+)
+
+func TestServiceGetProject(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+	s.AddTerm(1, poedit.Term{Term: "goodbye"})
+	s.SetTranslation(1, "da", poedit.TermKey{Term: "hello"}, poedit.TranslationContent{Singular: "Hej"})
+
+	client := poedit.NewClient("token", s.Client(), poedit.WithHostURL(s.URL))
+	downloader := outbound.NewDownloader(s.Client(), []string{"https"}, []string{"127.0.0.1"})
+
+	dir := t.TempDir()
+	if _, err := NewExporter(client, downloader).Export(context.Background(), 1, nil, testFormat, dir); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	snap, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+
+	svc := NewService(snap, time.Minute)
+
+	p, err := svc.GetProject(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	view, _ := s.Project(1)
+	if p.Name != "Website" || p.ReferenceLanguage != "en" || p.Terms != 2 || p.TTL != time.Minute {
+		t.Errorf("Unexpected project %+v", p)
+	}
+
+	if !p.Updated.Equal(view.Languages["da"].Updated.Truncate(time.Second)) {
+		t.Errorf("Expected the update time of the language, got %s", p.Updated)
+	}
+
+	var notFound *poedit.ErrProjectNotFound
+	if _, err := svc.GetProject(context.Background(), 2); !errors.As(err, &notFound) {
+		t.Errorf("Expected project not found, got %v", err)
+	}
+}
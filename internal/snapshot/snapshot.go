@@ -0,0 +1,256 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/internal/outbound"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// ManifestFile is the name of the manifest in a snapshot directory.
+const ManifestFile = "manifest.json"
+
+// Manifest describes the exported translations of a project in a snapshot directory.
+type Manifest struct {
+	ProjectID int `json:"projectId"`
+	// Name, ReferenceLanguage and Terms are the metadata of the project at the time of the export,
+	// and empty in manifests written before they were recorded.
+	Name              string              `json:"name,omitempty"`
+	ReferenceLanguage string              `json:"referenceLanguage,omitempty"`
+	Terms             int64               `json:"terms,omitempty"`
+	Format            string              `json:"format"`
+	Exported          time.Time           `json:"exported"`
+	Languages         map[string]Language `json:"languages"`
+}
+
+// Language is an exported translation of a snapshot.
+type Language struct {
+	// File is the name of the translation file, relative to the snapshot directory.
+	File string `json:"file"`
+	// Checksum is the md5 checksum of the file, as used for the etags of parrot.
+	Checksum string `json:"checksum"`
+	Size     int64  `json:"size"`
+	// Updated is the time of the latest change to the language in poeditor.
+	Updated time.Time `json:"updated"`
+}
+
+// Exporter exports the translations of projects from poeditor into snapshot directories.
+type Exporter struct {
+	client     poedit.Client
+	downloader *outbound.Downloader
+	filters    []string
+}
+
+// ExporterOption configures an Exporter.
+type ExporterOption func(e *Exporter)
+
+// WithFilters sets the poeditor filters of the exports, replacing the default filter of translated terms.
+func WithFilters(filters ...string) ExporterOption {
+	return func(e *Exporter) {
+		e.filters = filters
+	}
+}
+
+func NewExporter(client poedit.Client, downloader *outbound.Downloader, opts ...ExporterOption) *Exporter {
+	e := &Exporter{
+		client:     client,
+		downloader: downloader,
+		filters:    []string{"translated"},
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// Export downloads the languages of a project in the format into the directory, and writes the manifest.
+// Every language of the project is exported if no languages are given.
+// By default, the exports are filtered to translated terms, like the exports served by parrot, such that checksums match.
+func (e *Exporter) Export(ctx context.Context, projectID int, languages []string, format, dir string) (*Manifest, error) {
+	meta, err := poedit.GetContentMeta(format)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid format '%s'", format)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "Failed to create directory '%s'", dir)
+	}
+
+	view, err := e.client.ViewProject(ctx, poedit.ViewProjectRequest{ID: projectID})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to view project %d", projectID)
+	}
+
+	res, err := e.client.ListProjectLanguages(ctx, poedit.ListProjectLanguagesRequest{ID: projectID})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list languages of project %d", projectID)
+	}
+
+	updated := map[string]time.Time{}
+	for _, l := range res.Result.Languages {
+		t, err := poedit.ParseTime(l.Updated)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to parse update time of language '%s'", l.Code)
+		}
+
+		updated[l.Code] = t
+	}
+
+	if len(languages) == 0 {
+		for code := range updated {
+			languages = append(languages, code)
+		}
+		sort.Strings(languages)
+	}
+
+	manifest := &Manifest{
+		ProjectID:         projectID,
+		Name:              view.Result.Project.Name,
+		ReferenceLanguage: view.Result.Project.ReferenceLanguage,
+		Terms:             view.Result.Project.Terms,
+		Format:            format,
+		Exported:          time.Now().UTC(),
+		Languages:         map[string]Language{},
+	}
+
+	for _, code := range languages {
+		if _, ok := updated[code]; !ok {
+			return nil, &poedit.ErrLanguageNotFound{
+				ProjectID:    projectID,
+				LanguageCode: code,
+			}
+		}
+
+		file := fmt.Sprintf("%s.%s", code, meta.Extension)
+
+		checksum, size, err := e.exportLanguage(ctx, projectID, code, format, filepath.Join(dir, file))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to export language '%s'", code)
+		}
+
+		manifest.Languages[code] = Language{
+			File:     file,
+			Checksum: checksum,
+			Size:     size,
+			Updated:  updated[code],
+		}
+	}
+
+	if err := WriteManifest(dir, manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func (e *Exporter) exportLanguage(ctx context.Context, projectID int, languageCode, format, filePath string) (string, int64, error) {
+	resp, err := e.client.ExportProject(ctx, poedit.ExportProjectRequest{
+		ID:       projectID,
+		Language: languageCode,
+		Type:     format,
+		Filters:  e.filters,
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	body, err := e.downloader.Download(ctx, resp.Result.URL)
+	if err != nil {
+		return "", 0, err
+	}
+	defer body.Close()
+
+	return writeFile(filePath, body)
+}
+
+// writeFile streams r into a temporary file, which is moved into place once complete, and returns its md5 checksum and size.
+func writeFile(filePath string, r io.Reader) (string, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), ".parrot-*")
+	if err != nil {
+		return "", 0, errors.Wrap(err, "Failed to create temporary file")
+	}
+	defer os.Remove(tmp.Name())
+
+	hasher := md5.New()
+
+	size, err := io.Copy(io.MultiWriter(tmp, hasher), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", 0, errors.Wrap(err, "Failed to write file")
+	}
+
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return "", 0, errors.Wrap(err, "Failed to set file permissions")
+	}
+
+	if err := os.Rename(tmp.Name(), filePath); err != nil {
+		return "", 0, errors.Wrap(err, "Failed to move file into place")
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+// WriteManifest writes the manifest of a snapshot directory.
+func WriteManifest(dir string, manifest *Manifest) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal manifest")
+	}
+
+	if _, _, err := writeFile(filepath.Join(dir, ManifestFile), bytes.NewReader(b)); err != nil {
+		return errors.Wrap(err, "Failed to write manifest")
+	}
+
+	return nil
+}
+
+// ReadManifest reads the manifest of a snapshot directory.
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read manifest of snapshot '%s'", dir)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(b, &manifest); err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse manifest of snapshot '%s'", dir)
+	}
+
+	if err := manifest.validate(); err != nil {
+		return nil, errors.Wrapf(err, "Invalid manifest of snapshot '%s'", dir)
+	}
+
+	return &manifest, nil
+}
+
+// validate rejects the files of a manifest which are not within the snapshot directory,
+// such that a manifest can not make parrot read files elsewhere.
+func (m *Manifest) validate() error {
+	for code, l := range m.Languages {
+		file := filepath.ToSlash(l.File)
+		clean := path.Clean(file)
+
+		if file == "" || path.IsAbs(file) || filepath.IsAbs(l.File) || filepath.VolumeName(l.File) != "" || clean == ".." || strings.HasPrefix(clean, "../") {
+			return errors.Errorf("File '%s' of language %s is not within the snapshot directory", l.File, code)
+		}
+	}
+
+	return nil
+}
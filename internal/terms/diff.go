@@ -0,0 +1,134 @@
+package terms
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// Change is a term present both in poeditor and in the source, which differs between them.
+type Change struct {
+	Old Entry
+	New Entry
+}
+
+// TermChanged reports whether the plural, comment or reference of the term changed.
+func (c Change) TermChanged() bool {
+	return termChanged(c.Old, c.New)
+}
+
+// ContentChanged reports whether the translation of the term changed.
+func (c Change) ContentChanged() bool {
+	return contentChanged(c.Old, c.New)
+}
+
+// Changes are the differences between the terms of a project and a source.
+type Changes struct {
+	Added   []Entry
+	Removed []Entry
+	Changed []Change
+}
+
+// Empty reports whether the project and the source have the same terms.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// Diff computes the changes which bring the current terms of a project in line with the terms of a source.
+// Comments, references, translations and plural terms are only compared when the source has them,
+// since not every source format can express them.
+func Diff(current, source []Entry) Changes {
+	currentByKey := make(map[poedit.TermKey]Entry, len(current))
+	for _, e := range current {
+		currentByKey[e.Key()] = e
+	}
+
+	sourceKeys := make(map[poedit.TermKey]bool, len(source))
+
+	changes := Changes{
+		Added:   []Entry{},
+		Removed: []Entry{},
+		Changed: []Change{},
+	}
+
+	for _, e := range source {
+		sourceKeys[e.Key()] = true
+
+		old, ok := currentByKey[e.Key()]
+		if !ok {
+			changes.Added = append(changes.Added, e)
+
+			continue
+		}
+
+		if termChanged(old, e) || contentChanged(old, e) {
+			changes.Changed = append(changes.Changed, Change{Old: old, New: e})
+		}
+	}
+
+	for _, e := range current {
+		if !sourceKeys[e.Key()] {
+			changes.Removed = append(changes.Removed, e)
+		}
+	}
+
+	sort.Slice(changes.Removed, func(i, j int) bool {
+		return changes.Removed[i].Term.Term < changes.Removed[j].Term.Term
+	})
+
+	return changes
+}
+
+func termChanged(old, e Entry) bool {
+	// An implied plural only tells that the term has plural forms, not what its plural term is
+	pluralChanged := old.Plural != e.Plural
+	if e.PluralImplied {
+		pluralChanged = old.Plural == ""
+	}
+
+	return pluralChanged ||
+		(e.Comment != "" && old.Comment != e.Comment) ||
+		(e.Reference != "" && old.Reference != e.Reference)
+}
+
+func contentChanged(old, e Entry) bool {
+	if isEmpty(e.Content) {
+		return false
+	}
+
+	return old.Content.Singular != e.Content.Singular || !reflect.DeepEqual(nonNil(old.Content.Plural), nonNil(e.Content.Plural))
+}
+
+func isEmpty(c poedit.TranslationContent) bool {
+	return c.Singular == "" && len(c.Plural) == 0
+}
+
+func nonNil(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+
+	return m
+}
+
+// FromListTerms returns the entries of the terms of a project, along with the translations of the listed language.
+func FromListTerms(res *poedit.ListTermsResponse) []Entry {
+	entries := make([]Entry, 0, len(res.Result.Terms))
+
+	for _, t := range res.Result.Terms {
+		entries = append(entries, Entry{
+			Term: poedit.Term{
+				Term:      t.Term,
+				Context:   t.Context,
+				Reference: t.Reference,
+				Plural:    t.Plural,
+				Comment:   t.Comment,
+				Tags:      t.Tags,
+			},
+			Content: t.Translation.Content,
+		})
+	}
+
+	return entries
+}
@@ -0,0 +1,90 @@
+package terms
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+func TestDiffImpliedPlural(t *testing.T) {
+	source, err := Parse("key_value_json", strings.NewReader(`{"apple": {"one": "An apple", "other": "%d apples"}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		current Entry
+		changed bool
+		plural  string
+	}{
+		{
+			name: "plural term differing from the term is kept",
+			current: Entry{
+				Term:    poedit.Term{Term: "apple", Plural: "apples"},
+				Content: poedit.TranslationContent{Plural: map[string]string{"one": "An apple", "other": "%d apples"}},
+			},
+			changed: false,
+			plural:  "apples",
+		},
+		{
+			name: "plural term is kept when the translation changed",
+			current: Entry{
+				Term:    poedit.Term{Term: "apple", Plural: "apples"},
+				Content: poedit.TranslationContent{Plural: map[string]string{"one": "One apple", "other": "%d apples"}},
+			},
+			changed: true,
+			plural:  "apples",
+		},
+		{
+			name: "singular term becomes plural",
+			current: Entry{
+				Term:    poedit.Term{Term: "apple"},
+				Content: poedit.TranslationContent{Singular: "Apples"},
+			},
+			changed: true,
+			plural:  "apple",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff([]Entry{tt.current}, source)
+			if changed := len(changes.Changed) == 1; changed != tt.changed {
+				t.Fatalf("Expected changed to be %t, got %+v", tt.changed, changes)
+			}
+
+			if plural := mergeTerm(tt.current, source[0]).Plural; plural != tt.plural {
+				t.Errorf("Expected plural %q to be pushed, got %q", tt.plural, plural)
+			}
+		})
+	}
+}
+
+func TestDiffPluralFromPO(t *testing.T) {
+	source, err := Parse("po", strings.NewReader(`msgid "apple"
+msgid_plural "apples"
+msgstr[0] "An apple"
+msgstr[1] "%d apples"
+`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	current := Entry{
+		Term:    poedit.Term{Term: "apple", Plural: "the apples"},
+		Content: poedit.TranslationContent{Plural: map[string]string{"one": "An apple", "other": "%d apples"}},
+	}
+
+	changes := Diff([]Entry{current}, source)
+	if len(changes.Changed) != 1 || !changes.Changed[0].TermChanged() {
+		t.Fatalf("Expected the plural term of the po file to be compared, got %+v", changes)
+	}
+
+	if plural := mergeTerm(current, source[0]).Plural; plural != "apples" {
+		t.Errorf("Expected the plural term of the po file to be pushed, got %q", plural)
+	}
+}
@@ -0,0 +1,201 @@
+package terms
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// DefaultPatterns match the common translation calls of go, javascript, typescript and templates,
+// such as t("key"), i18n.t('key'), i18n.T(ctx, "key") and {{ T "key" }}.
+var DefaultPatterns = []string{
+	"\\b[tT]\\(\\s*[\"'`]([^\"'`]+)[\"'`]",
+	`\bi18n\.T\(\s*[^,()]+,\s*"([^"]+)"`,
+	`\{\{-?\s*[tT]\s+"([^"]+)"`,
+}
+
+// DefaultExtensions are the extensions of the files scanned for translation calls.
+var DefaultExtensions = []string{".go", ".js", ".jsx", ".ts", ".tsx", ".vue", ".html", ".tmpl", ".gohtml"}
+
+// skippedDirs are directories of dependencies and tooling, which are never scanned.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Extractor finds the terms used by source code.
+type Extractor struct {
+	patterns   []*regexp.Regexp
+	extensions map[string]bool
+}
+
+// NewExtractor returns an extractor which scans files with the extensions for the patterns.
+// The first group of a pattern, other than a group named context, captures the term.
+// A group named context, if present, captures the context of the term.
+func NewExtractor(patterns, extensions []string) (*Extractor, error) {
+	x := &Extractor{
+		extensions: make(map[string]bool, len(extensions)),
+	}
+
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid pattern '%s'", p)
+		}
+
+		if termGroup(re) < 0 {
+			return nil, errors.Errorf("Pattern '%s' has no group capturing the term", p)
+		}
+
+		x.patterns = append(x.patterns, re)
+	}
+
+	for _, ext := range extensions {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		x.extensions[ext] = true
+	}
+
+	return x, nil
+}
+
+// Extract scans the files below the paths, and returns the terms found, with the file:line of every use as reference.
+// The entries are sorted by term and context.
+func (x *Extractor) Extract(paths []string) ([]Entry, error) {
+	found := map[poedit.TermKey][]string{}
+
+	for _, root := range paths {
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				if path != root && skippedDirs[info.Name()] {
+					return filepath.SkipDir
+				}
+
+				return nil
+			}
+
+			if !x.extensions[filepath.Ext(path)] {
+				return nil
+			}
+
+			return x.extractFile(path, found)
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to scan %s", root)
+		}
+	}
+
+	entries := make([]Entry, 0, len(found))
+	for key, references := range found {
+		entries = append(entries, Entry{
+			Term: poedit.Term{
+				Term:      key.Term,
+				Context:   key.Context,
+				Reference: strings.Join(references, " "),
+			},
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Term.Term != entries[j].Term.Term {
+			return entries[i].Term.Term < entries[j].Term.Term
+		}
+
+		return entries[i].Context < entries[j].Context
+	})
+
+	return entries, nil
+}
+
+func (x *Extractor) extractFile(path string, found map[poedit.TermKey][]string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, re := range x.patterns {
+		group := termGroup(re)
+		contextGroup := re.SubexpIndex("context")
+
+		for _, m := range re.FindAllSubmatchIndex(content, -1) {
+			if m[2*group] < 0 {
+				continue
+			}
+
+			key := poedit.TermKey{Term: string(content[m[2*group]:m[2*group+1]])}
+			if contextGroup > 0 && m[2*contextGroup] >= 0 {
+				key.Context = string(content[m[2*contextGroup]:m[2*contextGroup+1]])
+			}
+
+			line := bytes.Count(content[:m[0]], []byte("\n")) + 1
+			found[key] = append(found[key], filepath.ToSlash(path)+":"+strconv.Itoa(line))
+		}
+	}
+
+	return nil
+}
+
+// Unused returns the current terms of a project which are not among the extracted terms.
+// Terms are matched by term and context, unless a pattern of the extractor does not capture the context.
+// Terms found by such a pattern may be used in any context, so terms are then matched by term alone.
+func (x *Extractor) Unused(current, extracted []Entry) []Entry {
+	termOnly := false
+	for _, re := range x.patterns {
+		if re.SubexpIndex("context") < 0 {
+			termOnly = true
+		}
+	}
+
+	used := make(map[poedit.TermKey]bool, len(extracted))
+	for _, e := range extracted {
+		used[e.Key()] = true
+
+		if termOnly {
+			used[poedit.TermKey{Term: e.Term.Term}] = true
+		}
+	}
+
+	unused := []Entry{}
+	for _, e := range current {
+		key := e.Key()
+		if termOnly {
+			key.Context = ""
+		}
+
+		if !used[key] {
+			unused = append(unused, e)
+		}
+	}
+
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].Term.Term < unused[j].Term.Term
+	})
+
+	return unused
+}
+
+// termGroup returns the index of the group of a pattern capturing the term, or -1 if it has none.
+func termGroup(re *regexp.Regexp) int {
+	for i, name := range re.SubexpNames() {
+		if i > 0 && name != "context" {
+			return i
+		}
+	}
+
+	return -1
+}
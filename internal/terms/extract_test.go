@@ -0,0 +1,166 @@
+package terms
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+func writeSources(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write source: %v", err)
+		}
+	}
+
+	return dir
+}
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		files    map[string]string
+		want     []Entry
+	}{
+		{
+			name:     "default patterns",
+			patterns: DefaultPatterns,
+			files: map[string]string{
+				"main.go":               "package main\n\nfunc main() {\n\tfmt.Println(i18n.T(ctx, \"hello\"))\n}\n",
+				"web/app.js":            "const a = t('hello');\nconst b = i18n.t(`goodbye`);\n",
+				"web/index.html":        "<h1>{{ T \"title\" }}</h1>\n",
+				"web/node_modules/x.js": "t('dependency')\n",
+				"README.md":             "t('documentation')\n",
+			},
+			want: []Entry{
+				{Term: poedit.Term{Term: "goodbye", Reference: "web/app.js:2"}},
+				{Term: poedit.Term{Term: "hello", Reference: "main.go:4 web/app.js:1"}},
+				{Term: poedit.Term{Term: "title", Reference: "web/index.html:1"}},
+			},
+		},
+		{
+			name:     "context group",
+			patterns: []string{`\bpt\(\s*"(?P<context>[^"]+)",\s*"([^"]+)"`},
+			files: map[string]string{
+				"menu.js": "pt(\"menu\", \"open\")\npt(\"dialog\", \"open\")\n",
+			},
+			want: []Entry{
+				{Term: poedit.Term{Term: "open", Context: "dialog", Reference: "menu.js:2"}},
+				{Term: poedit.Term{Term: "open", Context: "menu", Reference: "menu.js:1"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeSources(t, tt.files)
+
+			x, err := NewExtractor(tt.patterns, DefaultExtensions)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			entries, err := x.Extract([]string{dir})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// References are relative to the scanned directory, such that they do not depend on the temporary directory
+			for i := range entries {
+				entries[i].Reference = strings.ReplaceAll(entries[i].Reference, filepath.ToSlash(dir)+"/", "")
+			}
+
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, entries)
+			}
+		})
+	}
+}
+
+func TestNewExtractor(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		wantErr  bool
+	}{
+		{name: "default patterns", patterns: DefaultPatterns},
+		{name: "invalid pattern", patterns: []string{`t\(("`}, wantErr: true},
+		{name: "no term group", patterns: []string{`t\("[^"]+"\)`}, wantErr: true},
+		{name: "only context group", patterns: []string{`t\("(?P<context>[^"]+)"\)`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewExtractor(tt.patterns, nil); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestUnused(t *testing.T) {
+	current := []Entry{
+		{Term: poedit.Term{Term: "open", Context: "menu"}},
+		{Term: poedit.Term{Term: "open", Context: "dialog"}},
+		{Term: poedit.Term{Term: "removed"}},
+	}
+	extracted := []Entry{
+		{Term: poedit.Term{Term: "open", Context: "menu"}},
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     []string
+	}{
+		{
+			name:     "patterns capturing the context",
+			patterns: []string{`\bpt\(\s*"(?P<context>[^"]+)",\s*"([^"]+)"`},
+			want:     []string{"open/dialog", "removed/"},
+		},
+		{
+			name:     "pattern without context",
+			patterns: append([]string{`\bpt\(\s*"(?P<context>[^"]+)",\s*"([^"]+)"`}, DefaultPatterns...),
+			want:     []string{"removed/"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			x, err := NewExtractor(tt.patterns, nil)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got := []string{}
+			for _, e := range x.Unused(current, extracted) {
+				got = append(got, e.Term.Term+"/"+e.Context)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
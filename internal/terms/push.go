@@ -0,0 +1,216 @@
+package terms
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// ErrDeleteNotAllowed is returned when applying changes would delete terms, without deletion being allowed.
+var ErrDeleteNotAllowed = errors.New("Changes would delete terms from poeditor, which is not allowed")
+
+// PushOptions controls how changes are applied to a project.
+type PushOptions struct {
+	// Language is the language to push the translations of the source to. Translations are not pushed if empty.
+	Language string
+	// Delete allows removing terms from the project, which irreversibly deletes their translations in every language.
+	Delete bool
+	// Sync applies the terms with a single SyncProjectTerms call, instead of adding, updating and deleting terms.
+	Sync bool
+	// FuzzyTrigger marks the translations of terms with changed translations as fuzzy in the other languages.
+	FuzzyTrigger bool
+}
+
+// Current returns the terms of a project, along with their translations in the language if given.
+func Current(ctx context.Context, client poedit.Client, projectID int, language string) ([]Entry, error) {
+	res, err := client.ListTerms(ctx, poedit.ListTermsRequest{
+		ID:       projectID,
+		Language: language,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list terms of project %d", projectID)
+	}
+
+	return FromListTerms(res), nil
+}
+
+// Push applies changes, computed by Diff of the current terms of a project against the source, to the project.
+// Removed terms are left in the project unless deletion is allowed. Syncing with removed terms requires deletion to be allowed.
+// The references, comments and tags of current terms are kept, where the source has none.
+func Push(ctx context.Context, client poedit.Client, projectID int, current, source []Entry, changes Changes, opts PushOptions) error {
+	if opts.Sync {
+		if len(changes.Removed) > 0 && !opts.Delete {
+			return ErrDeleteNotAllowed
+		}
+
+		if err := syncTerms(ctx, client, projectID, current, source); err != nil {
+			return err
+		}
+	} else if err := updateTerms(ctx, client, projectID, changes, opts.Delete); err != nil {
+		return err
+	}
+
+	if opts.Language == "" {
+		return nil
+	}
+
+	return pushTranslations(ctx, client, projectID, opts.Language, changes, opts.FuzzyTrigger)
+}
+
+// mergeTerm returns the term of a source entry, with the reference, comment, tags and plural of the current term where the source has none.
+// Poeditor replaces the metadata of a term on sync and update, so it would be lost otherwise, as not every source format can express it.
+func mergeTerm(current, source Entry) poedit.Term {
+	t := source.Term
+
+	if source.PluralImplied && current.Plural != "" {
+		t.Plural = current.Plural
+	}
+
+	if t.Reference == "" {
+		t.Reference = current.Reference
+	}
+
+	if t.Comment == "" {
+		t.Comment = current.Comment
+	}
+
+	if len(t.Tags) == 0 {
+		t.Tags = current.Tags
+	}
+
+	return t
+}
+
+func syncTerms(ctx context.Context, client poedit.Client, projectID int, current, source []Entry) error {
+	currentByKey := make(map[poedit.TermKey]Entry, len(current))
+	for _, e := range current {
+		currentByKey[e.Key()] = e
+	}
+
+	req := poedit.SyncProjectTermsRequest{ID: projectID}
+
+	for _, e := range source {
+		t := e.Term
+		if old, ok := currentByKey[e.Key()]; ok {
+			t = mergeTerm(old, e)
+		}
+
+		if t.Tags == nil {
+			t.Tags = []string{}
+		}
+
+		req.Data = append(req.Data, struct {
+			Term      string   `json:"term"`
+			Context   string   `json:"context"`
+			Reference string   `json:"reference"`
+			Plural    string   `json:"plural"`
+			Comment   string   `json:"comment,omitempty"`
+			Tags      []string `json:"tags"`
+		}(t))
+	}
+
+	if _, err := client.SyncProjectTerms(ctx, req); err != nil {
+		return errors.Wrapf(err, "Failed to sync terms of project %d", projectID)
+	}
+
+	return nil
+}
+
+func updateTerms(ctx context.Context, client poedit.Client, projectID int, changes Changes, allowDelete bool) error {
+	if len(changes.Added) > 0 {
+		added := make([]poedit.Term, 0, len(changes.Added))
+		for _, e := range changes.Added {
+			added = append(added, e.Term)
+		}
+
+		if _, err := client.AddTerms(ctx, poedit.AddTermsRequest{ID: projectID, Terms: added}); err != nil {
+			return errors.Wrapf(err, "Failed to add terms to project %d", projectID)
+		}
+	}
+
+	var updated []poedit.TermUpdate
+	for _, c := range changes.Changed {
+		if !c.TermChanged() {
+			continue
+		}
+
+		t := mergeTerm(c.Old, c.New)
+
+		updated = append(updated, poedit.TermUpdate{
+			Term:      t.Term,
+			Context:   t.Context,
+			Reference: t.Reference,
+			Plural:    t.Plural,
+			Comment:   t.Comment,
+			Tags:      t.Tags,
+		})
+	}
+
+	if len(updated) > 0 {
+		if _, err := client.UpdateTerms(ctx, poedit.UpdateTermsRequest{ID: projectID, Terms: updated}); err != nil {
+			return errors.Wrapf(err, "Failed to update terms of project %d", projectID)
+		}
+	}
+
+	if len(changes.Removed) > 0 && allowDelete {
+		removed := make([]poedit.TermKey, 0, len(changes.Removed))
+		for _, e := range changes.Removed {
+			removed = append(removed, e.Key())
+		}
+
+		if _, err := client.DeleteTerms(ctx, poedit.DeleteTermsRequest{ID: projectID, Terms: removed}); err != nil {
+			return errors.Wrapf(err, "Failed to delete terms from project %d", projectID)
+		}
+	}
+
+	return nil
+}
+
+func pushTranslations(ctx context.Context, client poedit.Client, projectID int, language string, changes Changes, fuzzyTrigger bool) error {
+	var added, updated []poedit.Translation
+
+	for _, e := range changes.Added {
+		if !isEmpty(e.Content) {
+			added = append(added, poedit.NewTranslation(e.Term.Term, e.Context, e.Content, false))
+		}
+	}
+
+	for _, c := range changes.Changed {
+		if !c.ContentChanged() {
+			continue
+		}
+
+		t := poedit.NewTranslation(c.New.Term.Term, c.New.Context, c.New.Content, false)
+
+		// Only existing translations can be updated
+		if isEmpty(c.Old.Content) {
+			added = append(added, t)
+		} else {
+			updated = append(updated, t)
+		}
+	}
+
+	if len(added) > 0 {
+		if _, err := client.AddTranslations(ctx, poedit.AddTranslationsRequest{
+			ID:           projectID,
+			Language:     language,
+			Translations: added,
+		}); err != nil {
+			return errors.Wrapf(err, "Failed to add translations to language %s of project %d", language, projectID)
+		}
+	}
+
+	if len(updated) > 0 {
+		if _, err := client.UpdateTranslations(ctx, poedit.UpdateTranslationsRequest{
+			ID:           projectID,
+			Language:     language,
+			Translations: updated,
+			FuzzyTrigger: fuzzyTrigger,
+		}); err != nil {
+			return errors.Wrapf(err, "Failed to update translations of language %s of project %d", language, projectID)
+		}
+	}
+
+	return nil
+}
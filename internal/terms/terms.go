@@ -0,0 +1,468 @@
+package terms
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/pkg/poedit"
+	"gopkg.in/yaml.v2"
+)
+
+// Formats which can be parsed into terms.
+var Formats = []string{"key_value_json", "po", "arb", "yml"}
+
+// pluralForms are the plural categories of the unicode cldr, used by poeditor as keys of plural translations.
+var pluralForms = map[string]bool{
+	"zero":  true,
+	"one":   true,
+	"two":   true,
+	"few":   true,
+	"many":  true,
+	"other": true,
+}
+
+// poPluralForms are the plural categories of the msgstr indexes of po files for languages with more than two plural forms,
+// in the order of the plural expressions gettext uses for them.
+var poPluralForms = map[string][]string{
+	"ar": {"zero", "one", "two", "few", "many", "other"},
+	"be": {"one", "few", "many"},
+	"bs": {"one", "few", "other"},
+	"cs": {"one", "few", "other"},
+	"ga": {"one", "two", "few", "many", "other"},
+	"hr": {"one", "few", "other"},
+	"lt": {"one", "few", "other"},
+	"lv": {"zero", "one", "other"},
+	"pl": {"one", "few", "many"},
+	"ro": {"one", "few", "other"},
+	"ru": {"one", "few", "many"},
+	"sk": {"one", "few", "other"},
+	"sl": {"one", "two", "few", "other"},
+	"sr": {"one", "few", "other"},
+	"uk": {"one", "few", "many"},
+}
+
+// Entry is a term, along with its translation in the language of the file it was parsed from.
+type Entry struct {
+	poedit.Term
+	Content poedit.TranslationContent
+	// PluralImplied is set when the format of the source has no plural term of its own, such that Plural is only the term itself.
+	PluralImplied bool
+}
+
+// Key returns the key identifying the term of the entry.
+func (e Entry) Key() poedit.TermKey {
+	return poedit.TermKey{Term: e.Term.Term, Context: e.Context}
+}
+
+// Parse parses the terms of a file in a format of Formats.
+// The entries are sorted by term and context.
+func Parse(format string, r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var err error
+
+	switch format {
+	case "key_value_json":
+		entries, err = parseKeyValueJSON(r)
+	case "po":
+		entries, err = parsePO(r)
+	case "arb":
+		entries, err = parseARB(r)
+	case "yml":
+		entries, err = parseYAML(r)
+	default:
+		return nil, errors.Errorf("Parsing terms of format '%s' is not supported, use one of %s", format, strings.Join(Formats, ", "))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Term.Term != entries[j].Term.Term {
+			return entries[i].Term.Term < entries[j].Term.Term
+		}
+
+		return entries[i].Context < entries[j].Context
+	})
+
+	return entries, nil
+}
+
+// parseKeyValueJSON parses the key_value_json format of poeditor, in which terms with a context are nested in an object of the context.
+func parseKeyValueJSON(r io.Reader) ([]Entry, error) {
+	var data map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse key_value_json")
+	}
+
+	return parseKeyValues(data)
+}
+
+func parseYAML(r io.Reader) ([]Entry, error) {
+	var data map[string]interface{}
+	if err := yaml.NewDecoder(r).Decode(&data); err != nil && !errors.Is(err, io.EOF) {
+		return nil, errors.Wrap(err, "Failed to parse yml")
+	}
+
+	return parseKeyValues(data)
+}
+
+func parseKeyValues(data map[string]interface{}) ([]Entry, error) {
+	entries := []Entry{}
+
+	for key, value := range data {
+		if plural, ok := pluralContent(value); ok {
+			entries = append(entries, pluralEntry(key, "", plural))
+
+			continue
+		}
+
+		switch v := value.(type) {
+		case string:
+			entries = append(entries, Entry{
+				Term:    poedit.Term{Term: key},
+				Content: poedit.TranslationContent{Singular: v},
+			})
+		case map[string]interface{}, map[interface{}]interface{}:
+			group, _ := stringKeys(v)
+
+			for term, value := range group {
+				if plural, ok := pluralContent(value); ok {
+					entries = append(entries, pluralEntry(term, key, plural))
+
+					continue
+				}
+
+				s, ok := value.(string)
+				if !ok {
+					return nil, errors.Errorf("Unexpected value of term '%s' in context '%s'", term, key)
+				}
+
+				entries = append(entries, Entry{
+					Term:    poedit.Term{Term: term, Context: key},
+					Content: poedit.TranslationContent{Singular: s},
+				})
+			}
+		case nil:
+			entries = append(entries, Entry{Term: poedit.Term{Term: key}})
+		default:
+			return nil, errors.Errorf("Unexpected value of term '%s'", key)
+		}
+	}
+
+	return entries, nil
+}
+
+// pluralContent returns the plural forms of a value, if it is an object keyed by plural categories.
+func pluralContent(value interface{}) (map[string]string, bool) {
+	m, ok := stringKeys(value)
+	if !ok || len(m) == 0 {
+		return nil, false
+	}
+
+	plural := map[string]string{}
+	for form, v := range m {
+		s, ok := v.(string)
+		if !ok || !pluralForms[form] {
+			return nil, false
+		}
+
+		plural[form] = s
+	}
+
+	return plural, true
+}
+
+// stringKeys returns a json or yaml object as a map with string keys.
+func stringKeys(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, value := range v {
+			m[toString(k)] = value
+		}
+
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	b, _ := json.Marshal(v)
+
+	return string(b)
+}
+
+// pluralEntry returns the entry of a term with plural forms. Key value formats have no plural term of their own,
+// so the term is used as its own plural, which makes poeditor treat the term as one with plural forms.
+// The plural is marked as implied, such that the plural term of a term already in poeditor is kept.
+func pluralEntry(term, context string, plural map[string]string) Entry {
+	return Entry{
+		Term:          poedit.Term{Term: term, Context: context, Plural: term},
+		Content:       poedit.TranslationContent{Plural: plural},
+		PluralImplied: true,
+	}
+}
+
+// parseARB parses the application resource bundle format of flutter. Descriptions of messages become comments.
+func parseARB(r io.Reader) ([]Entry, error) {
+	var data map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return nil, errors.Wrap(err, "Failed to parse arb")
+	}
+
+	entries := []Entry{}
+
+	for key, value := range data {
+		if strings.HasPrefix(key, "@") {
+			continue
+		}
+
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("Unexpected value of message '%s'", key)
+		}
+
+		e := Entry{
+			Term:    poedit.Term{Term: key},
+			Content: poedit.TranslationContent{Singular: s},
+		}
+
+		if meta, ok := data["@"+key].(map[string]interface{}); ok {
+			if description, ok := meta["description"].(string); ok {
+				e.Comment = description
+			}
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// parsePO parses a gettext po file. Extracted comments become comments, and references become the reference.
+// The msgstr indexes of plural translations are mapped to plural categories by the Language and Plural-Forms of the header,
+// and by the plural forms of english if the file has no header.
+func parsePO(r io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+	forms := []string{"one", "other"}
+
+	var e Entry
+	var references []string
+	var plural map[string]string
+	// appendTo appends continuation strings to the field of the latest keyword
+	var appendTo func(s string)
+	// translated is set once the msgstr of the entry is read, after which another keyword starts the next entry
+	var translated bool
+
+	flush := func() error {
+		// The header entry has an empty msgid, and only gives the plural forms
+		if e.Term.Term == "" && e.Context == "" && translated {
+			var err error
+			if forms, err = poHeaderForms(e.Content.Singular); err != nil {
+				return err
+			}
+		}
+
+		if e.Term.Term != "" {
+			e.Reference = strings.Join(references, " ")
+
+			if plural != nil {
+				e.Content = poedit.TranslationContent{Plural: plural}
+			}
+
+			entries = append(entries, e)
+		}
+
+		e = Entry{}
+		references = nil
+		plural = nil
+		appendTo = nil
+		translated = false
+
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case text == "":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(text, "#"):
+			if translated {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+
+			switch {
+			case strings.HasPrefix(text, "#."):
+				if e.Comment != "" {
+					e.Comment += "\n"
+				}
+				e.Comment += strings.TrimSpace(text[2:])
+			case strings.HasPrefix(text, "#:"):
+				references = append(references, strings.Fields(text[2:])...)
+			}
+		case strings.HasPrefix(text, `"`):
+			if appendTo == nil {
+				return nil, errors.Errorf("Unexpected string on line %d of po file", line)
+			}
+
+			s, err := strconv.Unquote(text)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Invalid string on line %d of po file", line)
+			}
+
+			appendTo(s)
+		default:
+			keyword, value, err := poKeyword(text, line)
+			if err != nil {
+				return nil, err
+			}
+
+			if translated && !strings.HasPrefix(keyword, "msgstr") {
+				if err := flush(); err != nil {
+					return nil, err
+				}
+			}
+
+			switch {
+			case keyword == "msgctxt":
+				e.Context = value
+				appendTo = func(s string) { e.Context += s }
+			case keyword == "msgid":
+				e.Term.Term = value
+				appendTo = func(s string) { e.Term.Term += s }
+			case keyword == "msgid_plural":
+				e.Plural = value
+				appendTo = func(s string) { e.Plural += s }
+			case keyword == "msgstr":
+				e.Content.Singular = value
+				appendTo = func(s string) { e.Content.Singular += s }
+				translated = true
+			case strings.HasPrefix(keyword, "msgstr["):
+				if plural == nil {
+					plural = map[string]string{}
+				}
+
+				form, err := poPluralForm(keyword, forms, line)
+				if err != nil {
+					return nil, err
+				}
+
+				plural[form] = value
+				appendTo = func(s string) { plural[form] += s }
+				translated = true
+			default:
+				return nil, errors.Errorf("Unknown keyword '%s' on line %d of po file", keyword, line)
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to read po file")
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func poKeyword(text string, line int) (string, string, error) {
+	parts := strings.SplitN(text, " ", 2)
+	if len(parts) != 2 {
+		return "", "", errors.Errorf("Invalid line %d of po file", line)
+	}
+
+	value, err := strconv.Unquote(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return "", "", errors.Wrapf(err, "Invalid string on line %d of po file", line)
+	}
+
+	return parts[0], value, nil
+}
+
+// poHeaderForms returns the plural categories of the msgstr indexes of a po file, by the Language and Plural-Forms of its header.
+// Poeditor only accepts plural categories as keys of plural translations, so unknown plural forms are rejected.
+func poHeaderForms(header string) ([]string, error) {
+	var language string
+	nplurals := 2
+
+	for _, line := range strings.Split(header, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := strings.TrimSpace(parts[1])
+
+		switch strings.TrimSpace(parts[0]) {
+		case "Language":
+			if fields := strings.FieldsFunc(value, func(r rune) bool { return r == '_' || r == '-' || r == '@' }); len(fields) > 0 {
+				language = strings.ToLower(fields[0])
+			}
+		case "Plural-Forms":
+			for _, field := range strings.Split(value, ";") {
+				field = strings.TrimSpace(field)
+				if !strings.HasPrefix(field, "nplurals=") {
+					continue
+				}
+
+				n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(field, "nplurals=")))
+				if err != nil || n < 1 {
+					return nil, errors.Errorf("Invalid Plural-Forms '%s' in header of po file", value)
+				}
+
+				nplurals = n
+			}
+		}
+	}
+
+	switch nplurals {
+	case 1:
+		return []string{"other"}, nil
+	case 2:
+		return []string{"one", "other"}, nil
+	}
+
+	if forms, ok := poPluralForms[language]; ok && len(forms) == nplurals {
+		return forms, nil
+	}
+
+	return nil, errors.Errorf("The %d plural forms of language '%s' in the header of the po file are not supported", nplurals, language)
+}
+
+// poPluralForm maps the index of a msgstr plural to a plural category of the plural forms of the file.
+func poPluralForm(keyword string, forms []string, line int) (string, error) {
+	index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(keyword, "msgstr["), "]"))
+	if err != nil || !strings.HasSuffix(keyword, "]") {
+		return "", errors.Errorf("Invalid keyword '%s' on line %d of po file", keyword, line)
+	}
+
+	if index < 0 || index >= len(forms) {
+		return "", errors.Errorf("Plural form %d on line %d exceeds the %d plural forms of the po file", index, line, len(forms))
+	}
+
+	return forms[index], nil
+}
@@ -0,0 +1,212 @@
+package terms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+func TestParsePOPluralForms(t *testing.T) {
+	const entry = `
+msgid "apple"
+msgid_plural "apples"
+msgstr[0] "jabłko"
+msgstr[1] "jabłka"
+msgstr[2] "jabłek"
+`
+
+	tests := []struct {
+		name   string
+		header string
+		body   string
+		plural map[string]string
+		err    bool
+	}{
+		{
+			name: "english without header",
+			body: `msgid "apple"
+msgid_plural "apples"
+msgstr[0] "An apple"
+msgstr[1] "%d apples"
+`,
+			plural: map[string]string{"one": "An apple", "other": "%d apples"},
+		},
+		{
+			name: "polish by header",
+			header: `"Language: pl_PL\n"
+"Plural-Forms: nplurals=3; plural=(n==1 ? 0 : n%10>=2 && n%10<=4 && (n%100<10 || n%100>=20) ? 1 : 2);\n"`,
+			body:   entry,
+			plural: map[string]string{"one": "jabłko", "few": "jabłka", "many": "jabłek"},
+		},
+		{
+			name: "single plural form",
+			header: `"Language: ja\n"
+"Plural-Forms: nplurals=1; plural=0;\n"`,
+			body: `msgid "apple"
+msgid_plural "apples"
+msgstr[0] "りんご"
+`,
+			plural: map[string]string{"other": "りんご"},
+		},
+		{
+			name: "more forms than the header",
+			header: `"Language: en\n"
+"Plural-Forms: nplurals=2; plural=(n != 1);\n"`,
+			body: entry,
+			err:  true,
+		},
+		{
+			name: "more forms than english without header",
+			body: entry,
+			err:  true,
+		},
+		{
+			name: "unknown language with many forms",
+			header: `"Language: xx\n"
+"Plural-Forms: nplurals=3; plural=(n%3);\n"`,
+			body: entry,
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.body
+			if tt.header != "" {
+				file = "msgid \"\"\nmsgstr \"\"\n" + tt.header + "\n\n" + tt.body
+			}
+
+			entries, err := Parse("po", strings.NewReader(file))
+			if tt.err {
+				if err == nil {
+					t.Fatalf("Expected an error, got %+v", entries)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(entries) != 1 || !reflect.DeepEqual(entries[0].Content.Plural, tt.plural) {
+				t.Errorf("Expected plural %v, got %+v", tt.plural, entries)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		file    string
+		want    []Entry
+		wantErr bool
+	}{
+		{
+			name:   "key_value_json",
+			format: "key_value_json",
+			file:   `{"hello": "Hej", "menu": {"open": "Åbn"}, "empty": null, "apple": {"one": "Et æble", "other": "%d æbler"}}`,
+			want: []Entry{
+				{Term: poedit.Term{Term: "apple", Plural: "apple"}, Content: poedit.TranslationContent{Plural: map[string]string{"one": "Et æble", "other": "%d æbler"}}, PluralImplied: true},
+				{Term: poedit.Term{Term: "empty"}},
+				{Term: poedit.Term{Term: "hello"}, Content: poedit.TranslationContent{Singular: "Hej"}},
+				{Term: poedit.Term{Term: "open", Context: "menu"}, Content: poedit.TranslationContent{Singular: "Åbn"}},
+			},
+		},
+		{
+			name:    "key_value_json with unexpected value",
+			format:  "key_value_json",
+			file:    `{"hello": 1}`,
+			wantErr: true,
+		},
+		{
+			name:   "yml",
+			format: "yml",
+			file:   "hello: Hej\nmenu:\n  open: Åbn\n",
+			want: []Entry{
+				{Term: poedit.Term{Term: "hello"}, Content: poedit.TranslationContent{Singular: "Hej"}},
+				{Term: poedit.Term{Term: "open", Context: "menu"}, Content: poedit.TranslationContent{Singular: "Åbn"}},
+			},
+		},
+		{
+			name:   "empty yml",
+			format: "yml",
+			file:   "",
+			want:   []Entry{},
+		},
+		{
+			name:   "arb",
+			format: "arb",
+			file:   `{"@@locale": "da", "hello": "Hej", "@hello": {"description": "Greeting"}}`,
+			want: []Entry{
+				{Term: poedit.Term{Term: "hello", Comment: "Greeting"}, Content: poedit.TranslationContent{Singular: "Hej"}},
+			},
+		},
+		{
+			name:   "po",
+			format: "po",
+			file: `#. Greeting
+#: main.go:1
+#: views/index.html:5
+msgid "hello"
+msgstr "Hej"
+
+msgctxt "menu"
+msgid ""
+"open "
+"file"
+msgstr "Åbn fil"
+`,
+			want: []Entry{
+				{Term: poedit.Term{Term: "hello", Comment: "Greeting", Reference: "main.go:1 views/index.html:5"}, Content: poedit.TranslationContent{Singular: "Hej"}},
+				{Term: poedit.Term{Term: "open file", Context: "menu"}, Content: poedit.TranslationContent{Singular: "Åbn fil"}},
+			},
+		},
+		{
+			name:    "po with unknown keyword",
+			format:  "po",
+			file:    "msgfoo \"hello\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "po with dangling string",
+			format:  "po",
+			file:    "\"hello\"\n",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported format",
+			format:  "xliff",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Parse(tt.format, strings.NewReader(tt.file))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %+v", entries)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(entries, tt.want) {
+				t.Errorf("Expected %+v, got %+v", tt.want, entries)
+			}
+		})
+	}
+}
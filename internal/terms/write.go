@@ -0,0 +1,99 @@
+package terms
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFormats are the formats which terms can be written in.
+var WriteFormats = []string{"key_value_json", "po"}
+
+// Write writes entries in a format of WriteFormats, which can be parsed by Parse.
+func Write(format string, w io.Writer, entries []Entry) error {
+	switch format {
+	case "key_value_json":
+		return writeKeyValueJSON(w, entries)
+	case "po":
+		return writePO(w, entries)
+	default:
+		return errors.Errorf("Writing terms in format '%s' is not supported, use one of %s", format, strings.Join(WriteFormats, ", "))
+	}
+}
+
+// writeKeyValueJSON writes the key_value_json format of poeditor. The format has no room for references and comments.
+func writeKeyValueJSON(w io.Writer, entries []Entry) error {
+	data := map[string]interface{}{}
+
+	for _, e := range entries {
+		var value interface{} = e.Content.Singular
+		if len(e.Content.Plural) > 0 {
+			value = e.Content.Plural
+		}
+
+		if e.Context == "" {
+			data[e.Term.Term] = value
+
+			continue
+		}
+
+		group, ok := data[e.Context].(map[string]interface{})
+		if !ok {
+			group = map[string]interface{}{}
+			data[e.Context] = group
+		}
+
+		group[e.Term.Term] = value
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return errors.Wrap(enc.Encode(data), "Failed to write key_value_json")
+}
+
+func writePO(w io.Writer, entries []Entry) error {
+	b := bufio.NewWriter(w)
+
+	fmt.Fprintln(b, `msgid ""`)
+	fmt.Fprintln(b, `msgstr ""`)
+	fmt.Fprintln(b, `"Content-Type: text/plain; charset=UTF-8\n"`)
+
+	for _, e := range entries {
+		fmt.Fprintln(b)
+
+		for _, line := range strings.Split(e.Comment, "\n") {
+			if line != "" {
+				fmt.Fprintf(b, "#. %s\n", line)
+			}
+		}
+
+		for _, reference := range strings.Fields(e.Reference) {
+			fmt.Fprintf(b, "#: %s\n", reference)
+		}
+
+		if e.Context != "" {
+			fmt.Fprintf(b, "msgctxt %s\n", strconv.Quote(e.Context))
+		}
+
+		fmt.Fprintf(b, "msgid %s\n", strconv.Quote(e.Term.Term))
+
+		if e.Plural == "" {
+			fmt.Fprintf(b, "msgstr %s\n", strconv.Quote(e.Content.Singular))
+
+			continue
+		}
+
+		fmt.Fprintf(b, "msgid_plural %s\n", strconv.Quote(e.Plural))
+		fmt.Fprintf(b, "msgstr[0] %s\n", strconv.Quote(e.Content.Plural["one"]))
+		fmt.Fprintf(b, "msgstr[1] %s\n", strconv.Quote(e.Content.Plural["other"]))
+	}
+
+	return errors.Wrap(b.Flush(), "Failed to write po")
+}
@@ -2,28 +2,343 @@ package poedit
 
 import (
 	"context"
+	"encoding/json"
+	"math/rand"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"gopkg.in/resty.v1"
 )
 
+const (
+	defaultHostURL = "https://api.poeditor.com"
+
+	defaultRetries          = 2
+	defaultRetryWait        = time.Millisecond * 200
+	defaultRetryMaxWait     = time.Second * 2
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = time.Second * 30
+	defaultUploadInterval   = time.Second * 30
+)
+
 // Client is an interface to poeditors api
 type Client interface {
+	ListProjects(ctx context.Context) (result *ListProjectsResponse, err error)
 	ExportProject(ctx context.Context, req ExportProjectRequest) (result *ExportProjectResponse, err error)
+	ViewProject(ctx context.Context, req ViewProjectRequest) (result *ViewProjectResponse, err error)
+	ListProjectLanguages(ctx context.Context, req ListProjectLanguagesRequest) (result *ListProjectLanguagesResponse, err error)
+	SyncProjectTerms(ctx context.Context, req SyncProjectTermsRequest) (result *SyncProjectTermsResponse, err error)
+	UploadProject(ctx context.Context, req UploadProjectRequest) (result *UploadProjectResponse, err error)
+	ListTerms(ctx context.Context, req ListTermsRequest) (result *ListTermsResponse, err error)
+	AddTerms(ctx context.Context, req AddTermsRequest) (result *AddTermsResponse, err error)
+	UpdateTerms(ctx context.Context, req UpdateTermsRequest) (result *UpdateTermsResponse, err error)
+	DeleteTerms(ctx context.Context, req DeleteTermsRequest) (result *DeleteTermsResponse, err error)
+	AddTranslations(ctx context.Context, req AddTranslationsRequest) (result *AddTranslationsResponse, err error)
+	UpdateTranslations(ctx context.Context, req UpdateTranslationsRequest) (result *UpdateTranslationsResponse, err error)
+	DeleteTranslations(ctx context.Context, req DeleteTranslationsRequest) (result *DeleteTranslationsResponse, err error)
+	ListContributors(ctx context.Context, req ListContributorsRequest) (result *ListContributorsResponse, err error)
+	AddContributor(ctx context.Context, req AddContributorRequest) (result *AddContributorResponse, err error)
+	RemoveContributor(ctx context.Context, req RemoveContributorRequest) (result *RemoveContributorResponse, err error)
 }
 
+var _ Client = (*ClientImpl)(nil)
+
 // ClientImpl is an implementation of the poeditor client interface
 type ClientImpl struct {
-	r *resty.Client
+	r            *resty.Client
+	retries      int
+	retryWait    time.Duration
+	retryMaxWait time.Duration
+	breaker      *circuitBreaker
+	uploads      *uploadThrottle
+}
+
+// Option configures a poeditor api client
+type Option func(c *ClientImpl)
+
+// WithHostURL makes the client send requests to another host than api.poeditor.com
+func WithHostURL(url string) Option {
+	return func(c *ClientImpl) {
+		c.r.SetHostURL(url)
+	}
+}
+
+// WithRetries sets the number of times transient failures of reads are retried,
+// with an exponential backoff starting at wait and capped at maxWait.
+// Writes are never retried, as a write may have been applied by poeditor even if the request failed.
+func WithRetries(retries int, wait, maxWait time.Duration) Option {
+	return func(c *ClientImpl) {
+		c.retries = retries
+		c.retryWait = wait
+		c.retryMaxWait = maxWait
+	}
+}
+
+// WithCircuitBreaker makes the client fail fast for the cooldown period,
+// once threshold consecutive requests have failed.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(c *ClientImpl) {
+		c.breaker = newCircuitBreaker(threshold, cooldown)
+	}
+}
+
+// WithUploadInterval sets the minimum time between uploads, which are queued until their turn.
+// Poeditor allows one upload every 30 seconds. Zero disables the queue, such that uploads
+// poeditor throttles fail with ErrUploadThrottled instead.
+func WithUploadInterval(interval time.Duration) Option {
+	return func(c *ClientImpl) {
+		c.uploads = newUploadThrottle(interval)
+	}
 }
 
 // NewClient creates a new poeditor api client
-func NewClient(apiToken string, httpClient *http.Client) *ClientImpl {
+func NewClient(apiToken string, httpClient *http.Client, opts ...Option) *ClientImpl {
 	r := resty.NewWithClient(httpClient)
 	r.FormData.Add("api_token", apiToken)
-	r.SetHostURL("https://api.poeditor.com")
+	r.SetHostURL(defaultHostURL)
+
+	c := &ClientImpl{
+		r:            r,
+		retries:      defaultRetries,
+		retryWait:    defaultRetryWait,
+		retryMaxWait: defaultRetryMaxWait,
+		breaker:      newCircuitBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+		uploads:      newUploadThrottle(defaultUploadInterval),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type responseEnvelope struct {
+	Response struct {
+		Code string `json:"code"`
+	} `json:"response"`
+}
+
+// query sends a form to an endpoint of the poeditor api which only reads, and unmarshals the response into result.
+// Transient failures are retried with backoff, and fail fast while the circuit breaker is open.
+func (c *ClientImpl) query(ctx context.Context, path string, form map[string]string, result interface{}) (*resty.Response, error) {
+	return c.send(ctx, path, result, c.retries, func(req *resty.Request) {
+		req.SetFormData(form)
+	})
+}
+
+// post sends a form to an endpoint of the poeditor api which writes, and unmarshals the response into result.
+// The request is not retried, and fails fast while the circuit breaker is open.
+func (c *ClientImpl) post(ctx context.Context, path string, form map[string]string, result interface{}) (*resty.Response, error) {
+	return c.send(ctx, path, result, 0, func(req *resty.Request) {
+		req.SetFormData(form)
+	})
+}
+
+// send sends a request built by prepare to an endpoint of the poeditor api, and unmarshals the response into result.
+// Transient failures are retried up to retries times. Prepare is called once per attempt, such that request bodies can be recreated on retries.
+func (c *ClientImpl) send(ctx context.Context, path string, result interface{}, retries int, prepare func(req *resty.Request)) (*resty.Response, error) {
+	if !c.breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	for attempt := 0; ; attempt++ {
+		req := c.r.R()
+
+		prepare(req)
+
+		req.SetContext(ctx)
+
+		req.SetResult(result)
+
+		resp, err := req.Post(path)
+		if ctx.Err() != nil {
+			c.breaker.release()
+
+			return nil, ctx.Err()
+		}
+
+		err = c.classify(resp, err)
+		if err == nil {
+			c.breaker.success()
+
+			return resp, nil
+		}
+
+		if attempt >= retries {
+			var rateLimited *ErrRateLimited
+			if errors.As(err, &rateLimited) {
+				// A rate limited request is not a sign of poeditor being down, nor of it being up
+				c.breaker.release()
+			} else {
+				c.breaker.failure()
+			}
+
+			return nil, err
+		}
+
+		if err := sleep(ctx, c.backoff(attempt)); err != nil {
+			c.breaker.release()
+
+			return nil, err
+		}
+	}
+}
+
+// classify returns a typed error for the transient failures of a request, which are worth retrying.
+func (c *ClientImpl) classify(resp *resty.Response, err error) error {
+	if err != nil {
+		return &ErrUnavailable{Err: err}
+	}
+
+	if resp.StatusCode() == http.StatusTooManyRequests {
+		return &ErrRateLimited{RetryAfter: retryAfter(resp)}
+	}
+
+	if resp.StatusCode() >= http.StatusInternalServerError {
+		return &ErrUnavailable{Err: errors.Errorf("Response code '%d' from poeditor", resp.StatusCode())}
+	}
 
-	return &ClientImpl{
-		r: r,
+	var envelope responseEnvelope
+	if json.Unmarshal(resp.Body(), &envelope) == nil && envelope.Response.Code == codeRateLimited {
+		return &ErrRateLimited{RetryAfter: retryAfter(resp)}
 	}
+
+	return nil
+}
+
+// backoff returns the time to wait before retrying, as an exponential backoff with full jitter.
+func (c *ClientImpl) backoff(attempt int) time.Duration {
+	wait := c.retryWait << uint(attempt)
+	if wait <= 0 || wait > c.retryMaxWait {
+		wait = c.retryMaxWait
+	}
+
+	return time.Duration(rand.Int63n(int64(wait) + 1)) // nolint:gosec
+}
+
+func retryAfter(resp *resty.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header().Get("Retry-After"))
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// circuitBreaker opens after a number of consecutive failed requests, and stays open for a cooldown period.
+// After the cooldown a single request is let through, which closes the breaker again if it succeeds.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	mutex     *sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		mutex:     &sync.Mutex{},
+	}
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+
+	if time.Now().Before(b.openUntil) || b.probing {
+		return false
+	}
+
+	b.probing = true
+
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// release ends a request which neither succeeded nor failed, such as one cancelled by the caller.
+func (b *circuitBreaker) release() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.probing = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
+
+// uploadThrottle queues uploads, such that they are sent no more often than once per interval.
+type uploadThrottle struct {
+	interval time.Duration
+	slot     chan struct{}
+	next     time.Time
+}
+
+func newUploadThrottle(interval time.Duration) *uploadThrottle {
+	return &uploadThrottle{
+		interval: interval,
+		slot:     make(chan struct{}, 1),
+	}
+}
+
+// wait blocks until it is the turn of the caller to upload. The returned function must be called once the upload is done.
+func (t *uploadThrottle) wait(ctx context.Context) (func(), error) {
+	if t.interval <= 0 {
+		return func() {}, nil
+	}
+
+	select {
+	case t.slot <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	if err := sleep(ctx, time.Until(t.next)); err != nil {
+		<-t.slot
+
+		return nil, err
+	}
+
+	return func() {
+		t.next = time.Now().Add(t.interval)
+		<-t.slot
+	}, nil
 }
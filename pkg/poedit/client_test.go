@@ -0,0 +1,426 @@
+package poedit_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/pkg/poedit"
+	"github.com/uniwise/parrot/pkg/poedit/poedittest"
+)
+
+func newClient(s *poedittest.Server, opts ...poedit.Option) *poedit.ClientImpl {
+	opts = append([]poedit.Option{
+		poedit.WithHostURL(s.URL),
+		poedit.WithRetries(2, time.Millisecond, time.Millisecond),
+		poedit.WithUploadInterval(0),
+	}, opts...)
+
+	return poedit.NewClient("token", s.Client(), opts...)
+}
+
+func download(t *testing.T, s *poedittest.Server, url string) []byte {
+	t.Helper()
+
+	res, err := s.Client().Get(url)
+	if err != nil {
+		t.Fatalf("Failed to download export: %v", err)
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+
+	return b
+}
+
+func TestExportProjectFilters(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+	s.AddTerm(1, poedit.Term{Term: "goodbye"})
+	s.SetTranslation(1, "da", poedit.TermKey{Term: "hello"}, poedit.TranslationContent{Singular: "Hej"})
+
+	c := newClient(s)
+
+	tests := []struct {
+		filters  []string
+		expected map[string]string
+	}{
+		{filters: nil, expected: map[string]string{"hello": "Hej", "goodbye": ""}},
+		{filters: []string{"translated"}, expected: map[string]string{"hello": "Hej"}},
+		{filters: []string{"untranslated"}, expected: map[string]string{"goodbye": ""}},
+	}
+
+	for _, tt := range tests {
+		res, err := c.ExportProject(context.Background(), poedit.ExportProjectRequest{
+			ID:       1,
+			Language: "da",
+			Type:     "key_value_json",
+			Filters:  tt.filters,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		export := map[string]string{}
+		if err := json.Unmarshal(download(t, s, res.Result.URL), &export); err != nil {
+			t.Fatalf("Failed to unmarshal export: %v", err)
+		}
+
+		if len(export) != len(tt.expected) {
+			t.Errorf("Expected export with filters %v to be %v, got %v", tt.filters, tt.expected, export)
+		}
+
+		for term, translation := range tt.expected {
+			if v, ok := export[term]; !ok || v != translation {
+				t.Errorf("Expected export with filters %v to be %v, got %v", tt.filters, tt.expected, export)
+			}
+		}
+	}
+}
+
+func TestExportProjectLanguageNotFound(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+
+	_, err := newClient(s).ExportProject(context.Background(), poedit.ExportProjectRequest{
+		ID:       1,
+		Language: "da",
+		Type:     "json",
+	})
+
+	var notFound *poedit.ErrLanguageNotFound
+	if !errors.As(err, &notFound) || notFound.LanguageCode != "da" {
+		t.Errorf("Expected language not found, got %v", err)
+	}
+}
+
+func TestInvalidToken(t *testing.T) {
+	s := poedittest.NewServer(poedittest.WithToken("secret"))
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+
+	_, err := newClient(s).ViewProject(context.Background(), poedit.ViewProjectRequest{ID: 1})
+	if !errors.Is(err, poedit.ErrInvalidToken) {
+		t.Errorf("Expected invalid token, got %v", err)
+	}
+}
+
+func TestReadsAreRetried(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+	s.FailWithStatus("/v2/languages/list", http.StatusServiceUnavailable)
+
+	c := newClient(s, poedit.WithCircuitBreaker(0, 0))
+
+	_, err := c.ListProjectLanguages(context.Background(), poedit.ListProjectLanguagesRequest{ID: 1})
+
+	var unavailable *poedit.ErrUnavailable
+	if !errors.As(err, &unavailable) {
+		t.Errorf("Expected poeditor to be unavailable, got %v", err)
+	}
+
+	if n := s.Requests("/v2/languages/list"); n != 3 {
+		t.Errorf("Expected the request and 2 retries, got %d requests", n)
+	}
+
+	s.Recover()
+
+	if _, err := c.ListProjectLanguages(context.Background(), poedit.ListProjectLanguagesRequest{ID: 1}); err != nil {
+		t.Errorf("Unexpected error after recovering: %v", err)
+	}
+}
+
+func TestWritesAreNotRetried(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+	s.FailWithStatus("/v2/terms/add", http.StatusServiceUnavailable)
+
+	_, err := newClient(s).AddTerms(context.Background(), poedit.AddTermsRequest{
+		ID:    1,
+		Terms: []poedit.Term{{Term: "hello"}},
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if n := s.Requests("/v2/terms/add"); n != 1 {
+		t.Errorf("Expected a single request, got %d", n)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+	s.FailWithStatus("/v2/projects/view", http.StatusBadGateway)
+
+	c := newClient(s, poedit.WithRetries(0, 0, 0), poedit.WithCircuitBreaker(2, time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.ViewProject(context.Background(), poedit.ViewProjectRequest{ID: 1}); err == nil {
+			t.Fatal("Expected an error")
+		}
+	}
+
+	_, err := c.ViewProject(context.Background(), poedit.ViewProjectRequest{ID: 1})
+	if !errors.Is(err, poedit.ErrCircuitOpen) {
+		t.Errorf("Expected the circuit to be open, got %v", err)
+	}
+
+	if n := s.Requests("/v2/projects/view"); n != 2 {
+		t.Errorf("Expected no requests while the circuit is open, got %d requests", n)
+	}
+}
+
+func TestRateLimitKeepsCircuitClosed(t *testing.T) {
+	s := poedittest.NewServer(poedittest.WithRateLimit(1, time.Hour))
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+
+	c := newClient(s, poedit.WithRetries(0, 0, 0), poedit.WithCircuitBreaker(1, time.Hour))
+
+	if _, err := c.ViewProject(context.Background(), poedit.ViewProjectRequest{ID: 1}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := c.ViewProject(context.Background(), poedit.ViewProjectRequest{ID: 1})
+
+		var rateLimited *poedit.ErrRateLimited
+		if !errors.As(err, &rateLimited) {
+			t.Errorf("Expected the request to be rate limited, got %v", err)
+		}
+	}
+}
+
+func TestTranslations(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	hello := poedit.TermKey{Term: "hello"}
+	goodbye := poedit.TermKey{Term: "goodbye"}
+
+	s.AddProject(1, "Website", "en")
+	s.AddTerm(1, poedit.Term{Term: "goodbye"})
+	s.SetTranslation(1, "da", hello, poedit.TranslationContent{Singular: "Hej"})
+	s.SetTranslation(1, "de", hello, poedit.TranslationContent{Singular: "Hallo"})
+
+	c := newClient(s)
+	ctx := context.Background()
+
+	added, err := c.AddTranslations(ctx, poedit.AddTranslationsRequest{
+		ID:       1,
+		Language: "da",
+		Translations: []poedit.Translation{
+			poedit.NewTranslation("hello", "", poedit.TranslationContent{Singular: "Goddag"}, false),
+			poedit.NewTranslation("goodbye", "", poedit.TranslationContent{Singular: "Farvel"}, true),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if added.Result.Translations.Added != 1 {
+		t.Errorf("Expected only the missing translation to be added, got %d", added.Result.Translations.Added)
+	}
+
+	_, err = c.UpdateTranslations(ctx, poedit.UpdateTranslationsRequest{
+		ID:       1,
+		Language: "da",
+		Translations: []poedit.Translation{
+			poedit.NewTranslation("hello", "", poedit.TranslationContent{Singular: "Goddag"}, false),
+		},
+		FuzzyTrigger: true,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p, _ := s.Project(1)
+	da, de := p.Languages["da"], p.Languages["de"]
+
+	if da.Translations[hello].Singular != "Goddag" || da.Translations[goodbye].Singular != "Farvel" {
+		t.Errorf("Unexpected translations %v", da.Translations)
+	}
+
+	if !da.Fuzzy[goodbye] || da.Fuzzy[hello] || !de.Fuzzy[hello] {
+		t.Errorf("Expected goodbye in da and hello in de to be fuzzy, got %v and %v", da.Fuzzy, de.Fuzzy)
+	}
+
+	_, err = c.DeleteTranslations(ctx, poedit.DeleteTranslationsRequest{
+		ID:       1,
+		Language: "da",
+		Terms:    []poedit.TermKey{goodbye},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	p, _ = s.Project(1)
+	if _, ok := p.Languages["da"].Translations[goodbye]; ok || len(p.Terms) != 2 {
+		t.Errorf("Expected the translation to be deleted and the term kept, got %v and %d terms", p.Languages["da"].Translations, len(p.Terms))
+	}
+}
+
+func TestContributors(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+	s.AddLanguage(1, "da")
+	s.AddLanguage(1, "de")
+
+	c := newClient(s)
+	ctx := context.Background()
+
+	for _, req := range []poedit.AddContributorRequest{
+		{ID: 1, Name: "Translator", Email: "translator@example.com", Language: "da", Proofreader: true},
+		{ID: 1, Name: "Translator", Email: "translator@example.com", Language: "de", Proofreader: true},
+		{ID: 1, Name: "Admin", Email: "admin@example.com", Role: poedit.RoleAdministrator},
+	} {
+		if _, err := c.AddContributor(ctx, req); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	_, err := c.AddContributor(ctx, poedit.AddContributorRequest{ID: 1, Email: "other@example.com", Language: "fr"})
+
+	var notFound *poedit.ErrLanguageNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("Expected language not found, got %v", err)
+	}
+
+	if _, err := c.RemoveContributor(ctx, poedit.RemoveContributorRequest{ID: 1, Email: "translator@example.com", Language: "de"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	res, err := c.ListContributors(ctx, poedit.ListContributorsRequest{ID: 1})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	contributors := res.Result.Contributors
+	if len(contributors) != 2 {
+		t.Fatalf("Expected 2 contributors, got %d", len(contributors))
+	}
+
+	if contributors[0].Email != "admin@example.com" || contributors[0].Permissions[0].Type != poedit.RoleAdministrator {
+		t.Errorf("Expected an administrator, got %+v", contributors[0])
+	}
+
+	translator := contributors[1].Permissions[0]
+	if translator.Type != poedit.RoleContributor || !translator.Proofreader || strings.Join(translator.Languages, ",") != "da" {
+		t.Errorf("Expected a proofreader of da, got %+v", translator)
+	}
+
+	res, err = c.ListContributors(ctx, poedit.ListContributorsRequest{ID: 1, Language: "de"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(res.Result.Contributors) != 1 || res.Result.Contributors[0].Email != "admin@example.com" {
+		t.Errorf("Expected only the administrator to contribute to de, got %+v", res.Result.Contributors)
+	}
+}
+
+func TestUploadProject(t *testing.T) {
+	s := poedittest.NewServer()
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+	s.AddTerm(1, poedit.Term{Term: "obsolete"})
+	s.SetTranslation(1, "da", poedit.TermKey{Term: "hello"}, poedit.TranslationContent{Singular: "Hej"})
+
+	file := `[
+		{"term": "hello", "definition": "Goddag", "context": ""},
+		{"term": "apples", "definition": {"one": "æble", "other": "æbler"}, "context": "fruit", "term_plural": "apple"},
+		{"term": "untranslated", "definition": null, "context": ""}
+	]`
+
+	upload := func(c *poedit.ClientImpl, overwrite, sync bool) (*poedit.UploadProjectResponse, error) {
+		return c.UploadProject(context.Background(), poedit.UploadProjectRequest{
+			ID:        1,
+			Updating:  poedit.UpdatingTermsTranslations,
+			File:      strings.NewReader(file),
+			FileName:  "da.json",
+			Language:  "da",
+			Overwrite: overwrite,
+			SyncTerms: sync,
+		})
+	}
+
+	c := newClient(s)
+
+	res, err := upload(c, false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if res.Result.Terms.Added != 2 || res.Result.Translations.Added != 1 || res.Result.Translations.Updated != 0 {
+		t.Errorf("Unexpected result %+v", res.Result)
+	}
+
+	p, _ := s.Project(1)
+	if p.Languages["da"].Translations[poedit.TermKey{Term: "hello"}].Singular != "Hej" {
+		t.Error("Expected the existing translation to be kept without overwrite")
+	}
+
+	apples := p.Languages["da"].Translations[poedit.TermKey{Term: "apples", Context: "fruit"}]
+	if apples.Plural["other"] != "æbler" {
+		t.Errorf("Expected the plural translation to be added, got %+v", apples)
+	}
+
+	res, err = upload(c, true, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if res.Result.Terms.Deleted != 1 || res.Result.Translations.Updated != 2 {
+		t.Errorf("Unexpected result %+v", res.Result)
+	}
+
+	p, _ = s.Project(1)
+	if len(p.Terms) != 3 || p.Languages["da"].Translations[poedit.TermKey{Term: "hello"}].Singular != "Goddag" {
+		t.Errorf("Expected the obsolete term to be deleted and the translation overwritten, got %d terms and %v", len(p.Terms), p.Languages["da"].Translations)
+	}
+}
+
+func TestUploadProjectThrottled(t *testing.T) {
+	s := poedittest.NewServer(poedittest.WithUploadInterval(time.Hour))
+	defer s.Close()
+
+	s.AddProject(1, "Website", "en")
+
+	c := newClient(s)
+
+	for i, expected := range []error{nil, poedit.ErrUploadThrottled} {
+		_, err := c.UploadProject(context.Background(), poedit.UploadProjectRequest{
+			ID:       1,
+			Updating: poedit.UpdatingTerms,
+			File:     strings.NewReader(`[{"term": "hello", "context": ""}]`),
+			FileName: "terms.json",
+		})
+		if !errors.Is(err, expected) {
+			t.Errorf("Expected upload %d to return %v, got %v", i, expected, err)
+		}
+	}
+}
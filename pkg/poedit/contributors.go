@@ -0,0 +1,176 @@
+package poedit
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContributorRole is the role of a contributor in a project.
+type ContributorRole string
+
+const (
+	// RoleAdministrator can manage the project, and translate every language of it.
+	RoleAdministrator ContributorRole = "administrator"
+	// RoleContributor can translate the languages it is assigned to.
+	RoleContributor ContributorRole = "contributor"
+)
+
+type ListContributorsRequest struct {
+	// ID is optional. If set, only contributors of the project are returned.
+	ID int
+	// Language is optional. If set along with ID, only contributors of the language are returned.
+	Language string
+}
+
+type ListContributorsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Contributors []struct {
+			Name        string `json:"name"`
+			Email       string `json:"email"`
+			Permissions []struct {
+				Project struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"project"`
+				Type        ContributorRole `json:"type"`
+				Proofreader bool            `json:"proofreader"`
+				Languages   []string        `json:"languages"`
+			} `json:"permissions"`
+		} `json:"contributors"`
+	} `json:"result"`
+}
+
+// ListContributors Returns the list of contributors from all projects owned by user, or from a project or language.
+//
+// https://poeditor.com/docs/api#contributors_list
+func (c *ClientImpl) ListContributors(ctx context.Context, r ListContributorsRequest) (*ListContributorsResponse, error) {
+	form := map[string]string{}
+
+	if r.ID != 0 {
+		form["id"] = fmt.Sprintf("%d", r.ID)
+	}
+
+	if r.Language != "" {
+		form["language"] = r.Language
+	}
+
+	resp, err := c.query(ctx, "/v2/contributors/list", form, &ListContributorsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*ListContributorsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type AddContributorRequest struct {
+	ID    int
+	Name  string
+	Email string
+	// Role defaults to contributor. Administrators are added to the project rather than to a language.
+	Role ContributorRole
+	// Language is required for contributors.
+	Language string
+	// Proofreader lets a contributor mark translations of the language as proofread.
+	Proofreader bool
+}
+
+type AddContributorResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+}
+
+// AddContributor Adds a contributor to a project language or an administrator to a project.
+//
+// https://poeditor.com/docs/api#contributors_add
+func (c *ClientImpl) AddContributor(ctx context.Context, r AddContributorRequest) (*AddContributorResponse, error) {
+	form := map[string]string{
+		"id":    fmt.Sprintf("%d", r.ID),
+		"name":  r.Name,
+		"email": r.Email,
+	}
+
+	if r.Role == RoleAdministrator {
+		form["admin"] = formDataBool(true)
+	} else {
+		form["language"] = r.Language
+		form["proofreader"] = formDataBool(r.Proofreader)
+	}
+
+	resp, err := c.post(ctx, "/v2/contributors/add", form, &AddContributorResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*AddContributorResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type RemoveContributorRequest struct {
+	ID    int
+	Email string
+	// Language is optional. If set, the contributor is only removed from the language.
+	Language string
+}
+
+type RemoveContributorResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+}
+
+// RemoveContributor Removes a contributor from a project language or an administrator from a project.
+//
+// https://poeditor.com/docs/api#contributors_remove
+func (c *ClientImpl) RemoveContributor(ctx context.Context, r RemoveContributorRequest) (*RemoveContributorResponse, error) {
+	form := map[string]string{
+		"id":    fmt.Sprintf("%d", r.ID),
+		"email": r.Email,
+	}
+
+	if r.Language != "" {
+		form["language"] = r.Language
+	}
+
+	resp, err := c.post(ctx, "/v2/contributors/remove", form, &RemoveContributorResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*RemoveContributorResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
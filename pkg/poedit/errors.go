@@ -2,15 +2,60 @@ package poedit
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+// Response codes of the poeditor api.
+//
+// https://poeditor.com/docs/api_error_codes
+const (
+	codeOK               = "200"
+	codeRateLimited      = "429"
+	codePermissionDenied = "403"
+	codeInvalidToken     = "4010"
+	codeTokenNotFound    = "4011"
+	codeProjectNotFound  = "4012"
+	codeInvalidLanguage  = "4013"
+	codeLanguageNotFound = "4044"
+	codeUploadThrottled  = "4048"
+)
+
 var (
 	ErrFailedToUnmarshalResponse = errors.New("Failed to unmarshal response")
 	ErrNotImplemented            = errors.New("Method is not implemented")
+	// ErrCircuitOpen is returned without contacting poeditor, while poeditor is considered down.
+	ErrCircuitOpen = errors.New("Poeditor is unavailable, circuit breaker is open")
+	// ErrInvalidToken is returned when poeditor does not accept the api token.
+	ErrInvalidToken = errors.New("Invalid poeditor api token")
+	// ErrUploadThrottled is returned when uploading more often than poeditor allows.
+	ErrUploadThrottled = errors.New("Too many uploads to poeditor, no more than one every 30 seconds is allowed")
 )
 
+// ErrRateLimited is returned when poeditor rejects a request due to rate limiting.
+type ErrRateLimited struct {
+	// RetryAfter is the time poeditor asked to wait before retrying, or zero if it did not say.
+	RetryAfter time.Duration
+}
+
+func (e *ErrRateLimited) Error() string {
+	return "Request was rate limited by poeditor"
+}
+
+// ErrUnavailable is returned when poeditor could not be reached, or failed to handle a request.
+type ErrUnavailable struct {
+	Err error
+}
+
+func (e *ErrUnavailable) Error() string {
+	return fmt.Sprintf("Poeditor is unavailable: %s", e.Err)
+}
+
+func (e *ErrUnavailable) Unwrap() error {
+	return e.Err
+}
+
 type ErrProjectPermissionDenied struct {
 	ProjectID int
 }
@@ -34,3 +79,66 @@ func (e *ErrLanguageNotFound) Error() string {
 		e.LanguageCode,
 	)
 }
+
+type ErrProjectNotFound struct {
+	ProjectID int
+}
+
+func (e *ErrProjectNotFound) Error() string {
+	return fmt.Sprintf("Project %d does not exist", e.ProjectID)
+}
+
+type ErrInvalidLanguage struct {
+	LanguageCode string
+}
+
+func (e *ErrInvalidLanguage) Error() string {
+	return fmt.Sprintf("Invalid language code %s", e.LanguageCode)
+}
+
+// ErrAPI is returned for unsuccessful response codes of poeditor, which have no dedicated error.
+type ErrAPI struct {
+	Code    string
+	Message string
+}
+
+func (e *ErrAPI) Error() string {
+	return fmt.Sprintf("Poeditor responded with code %s: %s", e.Code, e.Message)
+}
+
+// responseError returns the error of a poeditor response code, or nil if the code is successful.
+// The project and language of the request are attached to the errors concerning them.
+func responseError(code, message string, projectID int, languageCode string) error {
+	switch code {
+	case codeOK:
+		return nil
+	case codeRateLimited:
+		return &ErrRateLimited{}
+	case codePermissionDenied:
+		return &ErrProjectPermissionDenied{
+			ProjectID: projectID,
+		}
+	case codeInvalidToken, codeTokenNotFound:
+		return ErrInvalidToken
+	case codeProjectNotFound:
+		return &ErrProjectNotFound{
+			ProjectID: projectID,
+		}
+	case codeInvalidLanguage:
+		return &ErrInvalidLanguage{
+			LanguageCode: languageCode,
+		}
+	case codeLanguageNotFound:
+		return &ErrLanguageNotFound{
+			ProjectID:    projectID,
+			LanguageCode: languageCode,
+		}
+	case codeUploadThrottled:
+		return ErrUploadThrottled
+	default:
+		return &ErrAPI{
+			Code:    code,
+			Message: message,
+		}
+	}
+}
@@ -3,8 +3,6 @@ package poedit
 import (
 	"context"
 	"fmt"
-
-	"github.com/pkg/errors"
 )
 
 type ListAvailableLanguagesResponse struct {
@@ -25,13 +23,7 @@ type ListAvailableLanguagesResponse struct {
 //
 // https://poeditor.com/docs/api#languages_available
 func (c *ClientImpl) ListAvailableLanguages(ctx context.Context) (*ListAvailableLanguagesResponse, error) {
-	req := c.r.R()
-
-	req.SetContext(ctx)
-
-	req.SetResult(&ListAvailableLanguagesResponse{})
-
-	resp, err := req.Post("/v2/languages/available")
+	resp, err := c.query(ctx, "/v2/languages/available", nil, &ListAvailableLanguagesResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -41,8 +33,8 @@ func (c *ClientImpl) ListAvailableLanguages(ctx context.Context) (*ListAvailable
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, 0, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -73,17 +65,9 @@ type ListProjectLanguagesResponse struct {
 //
 // https://poeditor.com/docs/api#languages_list
 func (c *ClientImpl) ListProjectLanguages(ctx context.Context, r ListProjectLanguagesRequest) (*ListProjectLanguagesResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.query(ctx, "/v2/languages/list", map[string]string{
 		"id": fmt.Sprintf("%d", r.ID),
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&ListProjectLanguagesResponse{})
-
-	resp, err := req.Post("/v2/languages/list")
+	}, &ListProjectLanguagesResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -93,8 +77,8 @@ func (c *ClientImpl) ListProjectLanguages(ctx context.Context, r ListProjectLang
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -117,18 +101,10 @@ type AddProjectLanguageResponse struct {
 //
 // https://poeditor.com/docs/api#languages_add
 func (c *ClientImpl) AddProjectlanguage(ctx context.Context, r AddProjectLanguageRequest) (*AddProjectLanguageResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.post(ctx, "/v2/languages/add", map[string]string{
 		"id":       fmt.Sprintf("%d", r.ID),
 		"language": r.Language,
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&AddProjectLanguageResponse{})
-
-	resp, err := req.Post("/v2/languages/add")
+	}, &AddProjectLanguageResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +114,8 @@ func (c *ClientImpl) AddProjectlanguage(ctx context.Context, r AddProjectLanguag
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -191,18 +167,10 @@ type DeleteProjectLanguageResponse struct {
 //
 // https://poeditor.com/docs/api#languages_delete
 func (c *ClientImpl) DeleteProjectLanguage(ctx context.Context, r DeleteProjectLanguageRequest) (*DeleteProjectLanguageResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.post(ctx, "/v2/languages/delete", map[string]string{
 		"id":       fmt.Sprintf("%d", r.ID),
 		"language": r.Language,
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&DeleteProjectLanguageResponse{})
-
-	resp, err := req.Post("/v2/languages/delete")
+	}, &DeleteProjectLanguageResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -212,8 +180,8 @@ func (c *ClientImpl) DeleteProjectLanguage(ctx context.Context, r DeleteProjectL
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
 	}
 
 	return res, nil
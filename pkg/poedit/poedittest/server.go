@@ -0,0 +1,1430 @@
+// Package poedittest provides a fake poeditor api, for testing code that uses the poedit client.
+//
+// The fake keeps projects, languages, terms, translations and contributors in memory, and serves the exports
+// it hands out download urls for itself. Failures, latency and rate limits can be configured,
+// to test how callers handle an unreliable poeditor.
+//
+//	s := poedittest.NewServer()
+//	defer s.Close()
+//
+//	s.AddProject(123, "Website", "en")
+//	s.SetTranslation(123, "da", poedit.TermKey{Term: "hello"}, poedit.TranslationContent{Singular: "hej"})
+//
+//	client := poedit.NewClient("token", s.Client(), poedit.WithHostURL(s.URL))
+package poedittest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uniwise/parrot/pkg/poedit"
+)
+
+// maxUploadSize is the largest upload the fake accepts.
+const maxUploadSize = 32 << 20
+
+// Response codes returned by the fake, mirroring those of the poeditor api.
+const (
+	CodeOK               = "200"
+	CodeRateLimited      = "429"
+	CodePermissionDenied = "403"
+	CodeInvalidToken     = "4010"
+	CodeTokenNotFound    = "4011"
+	CodeProjectNotFound  = "4012"
+	CodeInvalidLanguage  = "4013"
+	CodeLanguageNotFound = "4044"
+	CodeUploadThrottled  = "4048"
+)
+
+// Project is a project of the fake.
+type Project struct {
+	ID                int
+	Name              string
+	Description       string
+	ReferenceLanguage string
+	Created           time.Time
+	Terms             []*Term
+	Languages         map[string]*Language
+	// Contributors are keyed by email.
+	Contributors map[string]*Contributor
+}
+
+// Term is a term of a project of the fake.
+type Term struct {
+	poedit.Term
+	Created time.Time
+	Updated time.Time
+}
+
+// Language is a language of a project of the fake.
+type Language struct {
+	Code         string
+	Updated      time.Time
+	Translations map[poedit.TermKey]poedit.TranslationContent
+	// Fuzzy holds the terms whose translation is marked as fuzzy.
+	Fuzzy map[poedit.TermKey]bool
+}
+
+// Contributor is a contributor of a project of the fake.
+type Contributor struct {
+	Name        string
+	Email       string
+	Role        poedit.ContributorRole
+	Proofreader bool
+	// Languages are the languages a contributor is assigned to. Administrators are assigned to the project instead.
+	Languages []string
+}
+
+type failure struct {
+	status  int
+	code    string
+	message string
+}
+
+// Server is a fake poeditor api, served over https by an httptest server.
+// Its client, returned by Client, trusts the certificate of the server.
+type Server struct {
+	*httptest.Server
+
+	mutex          *sync.Mutex
+	token          string
+	latency        time.Duration
+	rateLimit      int
+	ratePer        time.Duration
+	window         time.Time
+	hits           int
+	uploadInterval time.Duration
+	lastUpload     time.Time
+	failures       map[string]failure
+	requests       map[string]int
+	projects       map[int]*Project
+	downloads      map[string][]byte
+}
+
+// Option configures the fake.
+type Option func(s *Server)
+
+// WithToken makes the fake reject requests with another api token than the given one.
+// By default every token is accepted.
+func WithToken(token string) Option {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithLatency delays every response of the fake, including downloads.
+func WithLatency(latency time.Duration) Option {
+	return func(s *Server) {
+		s.latency = latency
+	}
+}
+
+// WithRateLimit makes the fake reject api requests beyond limit per period, with the rate limit response code.
+func WithRateLimit(limit int, per time.Duration) Option {
+	return func(s *Server) {
+		s.rateLimit = limit
+		s.ratePer = per
+	}
+}
+
+// WithUploadInterval makes the fake reject uploads sent less than interval after the previous one,
+// with the upload throttled response code, like poeditor does with 30 seconds.
+// By default uploads are not throttled.
+func WithUploadInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.uploadInterval = interval
+	}
+}
+
+// NewServer starts a fake poeditor api. It must be closed by the caller.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		mutex:     &sync.Mutex{},
+		failures:  map[string]failure{},
+		requests:  map[string]int{},
+		projects:  map[int]*Project{},
+		downloads: map[string][]byte{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// AddProject adds an empty project, replacing any existing project with the id.
+func (s *Server) AddProject(id int, name, referenceLanguage string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.projects[id] = &Project{
+		ID:                id,
+		Name:              name,
+		ReferenceLanguage: referenceLanguage,
+		Created:           time.Now(),
+		Languages:         map[string]*Language{},
+		Contributors:      map[string]*Contributor{},
+	}
+}
+
+// AddLanguage adds a language to a project, if it does not already have it.
+func (s *Server) AddLanguage(projectID int, code string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.language(s.project(projectID), code)
+}
+
+// AddTerm adds a term to a project, or replaces the term with the same term and context.
+func (s *Server) AddTerm(projectID int, term poedit.Term) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.addTerm(s.project(projectID), term)
+}
+
+// SetTranslation sets the translation of a term in a language of a project, adding the language and term if missing.
+func (s *Server) SetTranslation(projectID int, code string, key poedit.TermKey, content poedit.TranslationContent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p := s.project(projectID)
+
+	if findTerm(p, key) < 0 {
+		s.addTerm(p, poedit.Term{Term: key.Term, Context: key.Context})
+	}
+
+	l := s.language(p, code)
+	l.Translations[key] = content
+	delete(l.Fuzzy, key)
+	l.Updated = time.Now()
+}
+
+// SetFuzzy marks the translation of a term in a language of a project as fuzzy, or not.
+func (s *Server) SetFuzzy(projectID int, code string, key poedit.TermKey, fuzzy bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	l := s.language(s.project(projectID), code)
+	setFuzzy(l, key, fuzzy)
+	l.Updated = time.Now()
+}
+
+// AddContributor adds a contributor to a project, or replaces the contributor with the same email.
+func (s *Server) AddContributor(projectID int, c Contributor) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c.Languages = append([]string{}, c.Languages...)
+	s.project(projectID).Contributors[c.Email] = &c
+}
+
+// Project returns a copy of a project, for asserting on the state of the fake.
+func (s *Server) Project(projectID int) (Project, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p, ok := s.projects[projectID]
+	if !ok {
+		return Project{}, false
+	}
+
+	c := *p
+	c.Terms = make([]*Term, 0, len(p.Terms))
+	for _, t := range p.Terms {
+		tc := *t
+		c.Terms = append(c.Terms, &tc)
+	}
+
+	c.Languages = map[string]*Language{}
+	for code, l := range p.Languages {
+		lc := *l
+		lc.Translations = map[poedit.TermKey]poedit.TranslationContent{}
+		for k, v := range l.Translations {
+			lc.Translations[k] = v
+		}
+		lc.Fuzzy = map[poedit.TermKey]bool{}
+		for k, v := range l.Fuzzy {
+			lc.Fuzzy[k] = v
+		}
+		c.Languages[code] = &lc
+	}
+
+	c.Contributors = map[string]*Contributor{}
+	for email, contributor := range p.Contributors {
+		cc := *contributor
+		cc.Languages = append([]string{}, contributor.Languages...)
+		c.Contributors[email] = &cc
+	}
+
+	return c, true
+}
+
+// Fail makes requests to the api path, such as "/v2/projects/export", respond with the response code and message.
+func (s *Server) Fail(path, code, message string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failures[path] = failure{status: http.StatusOK, code: code, message: message}
+}
+
+// FailWithStatus makes requests to the api path respond with the http status, such as 503.
+func (s *Server) FailWithStatus(path string, status int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failures[path] = failure{status: status}
+}
+
+// Recover makes requests to every api path succeed again.
+func (s *Server) Recover() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failures = map[string]failure{}
+}
+
+// Requests returns the number of requests to a path of the fake, including failed and rate limited ones.
+func (s *Server) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.requests[path]
+}
+
+// project returns a project, creating it if it does not exist. Must be called with the mutex held.
+func (s *Server) project(projectID int) *Project {
+	p, ok := s.projects[projectID]
+	if !ok {
+		p = &Project{
+			ID:           projectID,
+			Name:         fmt.Sprintf("Project %d", projectID),
+			Created:      time.Now(),
+			Languages:    map[string]*Language{},
+			Contributors: map[string]*Contributor{},
+		}
+		s.projects[projectID] = p
+	}
+
+	return p
+}
+
+func (s *Server) language(p *Project, code string) *Language {
+	l, ok := p.Languages[code]
+	if !ok {
+		l = &Language{
+			Code:         code,
+			Translations: map[poedit.TermKey]poedit.TranslationContent{},
+			Fuzzy:        map[poedit.TermKey]bool{},
+		}
+		p.Languages[code] = l
+	}
+
+	return l
+}
+
+func setFuzzy(l *Language, key poedit.TermKey, fuzzy bool) {
+	if fuzzy {
+		l.Fuzzy[key] = true
+	} else {
+		delete(l.Fuzzy, key)
+	}
+}
+
+func (s *Server) addTerm(p *Project, term poedit.Term) {
+	now := time.Now()
+
+	if i := findTerm(p, poedit.TermKey{Term: term.Term, Context: term.Context}); i >= 0 {
+		p.Terms[i].Term = term
+		p.Terms[i].Updated = now
+
+		return
+	}
+
+	p.Terms = append(p.Terms, &Term{
+		Term:    term,
+		Created: now,
+	})
+}
+
+func findTerm(p *Project, key poedit.TermKey) int {
+	for i, t := range p.Terms {
+		if t.Term.Term == key.Term && t.Context == key.Context {
+			return i
+		}
+	}
+
+	return -1
+}
+
+type handler func(r *http.Request) (code, message string, result interface{})
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.latency > 0 {
+		select {
+		case <-time.After(s.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests[r.URL.Path]++
+
+	if strings.HasPrefix(r.URL.Path, "/download/") {
+		s.download(w, r)
+
+		return
+	}
+
+	if f, ok := s.failures[r.URL.Path]; ok {
+		if f.status != http.StatusOK {
+			w.WriteHeader(f.status)
+
+			return
+		}
+
+		respond(w, f.code, f.message, nil)
+
+		return
+	}
+
+	if s.rateLimited() {
+		respond(w, CodeRateLimited, "Too many requests", nil)
+
+		return
+	}
+
+	// Uploads are sent as multipart forms, and every other request as url encoded forms
+	if err := r.ParseMultipartForm(maxUploadSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	if s.token != "" && r.PostForm.Get("api_token") != s.token {
+		respond(w, CodeInvalidToken, "Invalid API Token", nil)
+
+		return
+	}
+
+	handlers := map[string]handler{
+		"/v2/projects/list":       s.listProjects,
+		"/v2/projects/view":       s.viewProject,
+		"/v2/projects/sync":       s.syncProject,
+		"/v2/projects/export":     s.exportProject,
+		"/v2/projects/upload":     s.uploadProject,
+		"/v2/languages/list":      s.listLanguages,
+		"/v2/languages/add":       s.addLanguage,
+		"/v2/languages/delete":    s.deleteLanguage,
+		"/v2/terms/list":          s.listTerms,
+		"/v2/terms/add":           s.addTerms,
+		"/v2/terms/update":        s.updateTerms,
+		"/v2/terms/delete":        s.deleteTerms,
+		"/v2/translations/add":    s.addTranslations,
+		"/v2/translations/update": s.updateTranslations,
+		"/v2/translations/delete": s.deleteTranslations,
+		"/v2/contributors/list":   s.listContributors,
+		"/v2/contributors/add":    s.addContributor,
+		"/v2/contributors/remove": s.removeContributor,
+	}
+
+	h, ok := handlers[r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	code, message, result := h(r)
+	respond(w, code, message, result)
+}
+
+// rateLimited counts a request against the rate limit, in fixed windows. Must be called with the mutex held.
+func (s *Server) rateLimited() bool {
+	if s.rateLimit <= 0 {
+		return false
+	}
+
+	now := time.Now()
+	if now.Sub(s.window) >= s.ratePer {
+		s.window = now
+		s.hits = 0
+	}
+
+	s.hits++
+
+	return s.hits > s.rateLimit
+}
+
+func respond(w http.ResponseWriter, code, message string, result interface{}) {
+	status := "success"
+	if code != CodeOK {
+		status = "fail"
+	}
+
+	body := map[string]interface{}{
+		"response": map[string]string{
+			"status":  status,
+			"code":    code,
+			"message": message,
+		},
+	}
+
+	if result != nil {
+		body["result"] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// requestProject returns the project of the id of a request, or the response code if it does not exist.
+func (s *Server) requestProject(r *http.Request) (*Project, string, string) {
+	id, err := strconv.Atoi(r.PostForm.Get("id"))
+	if err != nil {
+		return nil, CodeProjectNotFound, "Invalid project id"
+	}
+
+	p, ok := s.projects[id]
+	if !ok {
+		return nil, CodeProjectNotFound, "Project does not exist"
+	}
+
+	return p, CodeOK, ""
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(poedit.TimeLayout)
+}
+
+func projectResult(p *Project) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                 p.ID,
+		"name":               p.Name,
+		"description":        p.Description,
+		"public":             0,
+		"open":               0,
+		"reference_language": p.ReferenceLanguage,
+		"terms":              len(p.Terms),
+		"created":            formatTime(p.Created),
+	}
+}
+
+func (s *Server) listProjects(r *http.Request) (string, string, interface{}) {
+	ids := make([]int, 0, len(s.projects))
+	for id := range s.projects {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	projects := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		p := s.projects[id]
+		projects = append(projects, map[string]interface{}{
+			"id":      p.ID,
+			"name":    p.Name,
+			"public":  0,
+			"open":    0,
+			"created": formatTime(p.Created),
+		})
+	}
+
+	return CodeOK, "OK", map[string]interface{}{"projects": projects}
+}
+
+func (s *Server) viewProject(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	return CodeOK, "OK", map[string]interface{}{"project": projectResult(p)}
+}
+
+func (s *Server) syncProject(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	var terms []poedit.Term
+	if err := json.Unmarshal([]byte(r.PostForm.Get("data")), &terms); err != nil {
+		return "4032", "Invalid data", nil
+	}
+
+	keep := map[poedit.TermKey]bool{}
+	added, updated := 0, 0
+
+	for _, t := range terms {
+		key := poedit.TermKey{Term: t.Term, Context: t.Context}
+		keep[key] = true
+
+		if findTerm(p, key) >= 0 {
+			updated++
+		} else {
+			added++
+		}
+
+		s.addTerm(p, t)
+	}
+
+	deleted := s.removeTerms(p, func(key poedit.TermKey) bool {
+		return !keep[key]
+	})
+
+	return CodeOK, "OK", map[string]interface{}{
+		"terms": map[string]int{
+			"parsed":  len(terms),
+			"added":   added,
+			"updated": updated,
+			"deleted": deleted,
+		},
+	}
+}
+
+// removeTerms removes the terms matching remove from a project, along with their translations.
+func (s *Server) removeTerms(p *Project, remove func(key poedit.TermKey) bool) int {
+	kept := p.Terms[:0]
+	removed := 0
+
+	for _, t := range p.Terms {
+		key := poedit.TermKey{Term: t.Term.Term, Context: t.Context}
+		if !remove(key) {
+			kept = append(kept, t)
+
+			continue
+		}
+
+		removed++
+
+		for _, l := range p.Languages {
+			delete(l.Translations, key)
+			delete(l.Fuzzy, key)
+		}
+	}
+
+	p.Terms = kept
+
+	return removed
+}
+
+// exportProject renders the export and hands out a download url for it, served by the fake itself.
+// The json and key_value_json types are rendered like poeditor does. Other types are rendered as key_value_json.
+// The translated, untranslated, fuzzy and not_fuzzy filters are applied. Other filters are rejected.
+func (s *Server) exportProject(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	l, ok := p.Languages[r.PostForm.Get("language")]
+	if !ok {
+		return CodeLanguageNotFound, "Language not found in project", nil
+	}
+
+	include, err := exportFilter(l, formArray(r.PostForm.Get("filters")))
+	if err != nil {
+		return "4032", err.Error(), nil
+	}
+
+	var export interface{}
+	if r.PostForm.Get("type") == "json" {
+		export = jsonExport(p, l, include)
+	} else {
+		export = keyValueExport(p, l, include)
+	}
+
+	b, err := json.MarshalIndent(export, "", "    ")
+	if err != nil {
+		return "500", err.Error(), nil
+	}
+
+	id := strconv.Itoa(len(s.downloads) + 1)
+	s.downloads[id] = b
+
+	return CodeOK, "OK", map[string]string{
+		"url": fmt.Sprintf("%s/download/%s", s.URL, id),
+	}
+}
+
+// exportFilter returns whether to export a term, according to the filters of an export.
+func exportFilter(l *Language, filters []string) (func(key poedit.TermKey) bool, error) {
+	checks := make([]func(key poedit.TermKey) bool, 0, len(filters))
+
+	for _, f := range filters {
+		switch f {
+		case "translated":
+			checks = append(checks, func(key poedit.TermKey) bool {
+				return translated(l.Translations[key])
+			})
+		case "untranslated":
+			checks = append(checks, func(key poedit.TermKey) bool {
+				return !translated(l.Translations[key])
+			})
+		case "fuzzy":
+			checks = append(checks, func(key poedit.TermKey) bool {
+				return l.Fuzzy[key]
+			})
+		case "not_fuzzy":
+			checks = append(checks, func(key poedit.TermKey) bool {
+				return !l.Fuzzy[key]
+			})
+		default:
+			return nil, errors.Errorf("Unsupported filter '%s'", f)
+		}
+	}
+
+	return func(key poedit.TermKey) bool {
+		for _, check := range checks {
+			if !check(key) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// translated returns whether a translation has any content.
+func translated(content poedit.TranslationContent) bool {
+	if content.Singular != "" {
+		return true
+	}
+
+	for _, v := range content.Plural {
+		if v != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formArray parses an array form value, sent as a json array, or as a single value.
+func formArray(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	var values []string
+	if err := json.Unmarshal([]byte(value), &values); err != nil {
+		return []string{value}
+	}
+
+	return values
+}
+
+func jsonExport(p *Project, l *Language, include func(key poedit.TermKey) bool) interface{} {
+	export := make([]interface{}, 0, len(p.Terms))
+
+	for _, t := range p.Terms {
+		if !include(poedit.TermKey{Term: t.Term.Term, Context: t.Context}) {
+			continue
+		}
+
+		var definition interface{}
+		if content, ok := l.Translations[poedit.TermKey{Term: t.Term.Term, Context: t.Context}]; ok {
+			definition = content
+		}
+
+		export = append(export, map[string]interface{}{
+			"term":        t.Term.Term,
+			"definition":  definition,
+			"context":     t.Context,
+			"term_plural": t.Plural,
+			"reference":   t.Reference,
+			"comment":     t.Comment,
+		})
+	}
+
+	return export
+}
+
+func keyValueExport(p *Project, l *Language, include func(key poedit.TermKey) bool) interface{} {
+	export := map[string]interface{}{}
+
+	for _, t := range p.Terms {
+		if !include(poedit.TermKey{Term: t.Term.Term, Context: t.Context}) {
+			continue
+		}
+
+		content, ok := l.Translations[poedit.TermKey{Term: t.Term.Term, Context: t.Context}]
+		if !ok {
+			content = poedit.TranslationContent{}
+		}
+
+		if t.Context == "" {
+			export[t.Term.Term] = content
+
+			continue
+		}
+
+		group, ok := export[t.Context].(map[string]interface{})
+		if !ok {
+			group = map[string]interface{}{}
+			export[t.Context] = group
+		}
+
+		group[t.Term.Term] = content
+	}
+
+	return export
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request) {
+	b, ok := s.downloads[strings.TrimPrefix(r.URL.Path, "/download/")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+func (s *Server) listLanguages(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	codes := make([]string, 0, len(p.Languages))
+	for c := range p.Languages {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+
+	languages := make([]interface{}, 0, len(codes))
+	for _, c := range codes {
+		l := p.Languages[c]
+
+		percentage := 0.0
+		if len(p.Terms) > 0 {
+			percentage = float64(len(l.Translations)) / float64(len(p.Terms)) * 100
+		}
+
+		languages = append(languages, map[string]interface{}{
+			"name":         l.Code,
+			"code":         l.Code,
+			"translations": len(l.Translations),
+			"percentage":   percentage,
+			"updated":      formatTime(l.Updated),
+		})
+	}
+
+	return CodeOK, "OK", map[string]interface{}{"languages": languages}
+}
+
+func (s *Server) addLanguage(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	c := r.PostForm.Get("language")
+	if c == "" {
+		return CodeInvalidLanguage, "Invalid language code", nil
+	}
+
+	s.language(p, c)
+
+	return CodeOK, "Language successfully added", nil
+}
+
+func (s *Server) deleteLanguage(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	c := r.PostForm.Get("language")
+	if _, ok := p.Languages[c]; !ok {
+		return CodeLanguageNotFound, "Language not found in project", nil
+	}
+
+	delete(p.Languages, c)
+
+	return CodeOK, "Language successfully deleted", nil
+}
+
+func (s *Server) listTerms(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	var l *Language
+	if c := r.PostForm.Get("language"); c != "" {
+		var ok bool
+		if l, ok = p.Languages[c]; !ok {
+			return CodeLanguageNotFound, "Language not found in project", nil
+		}
+	}
+
+	terms := make([]interface{}, 0, len(p.Terms))
+	for _, t := range p.Terms {
+		tags := t.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+
+		term := map[string]interface{}{
+			"term":      t.Term.Term,
+			"context":   t.Context,
+			"plural":    t.Plural,
+			"created":   formatTime(t.Created),
+			"updated":   formatTime(t.Updated),
+			"reference": t.Reference,
+			"tags":      tags,
+			"comment":   t.Comment,
+		}
+
+		if l != nil {
+			content, ok := l.Translations[poedit.TermKey{Term: t.Term.Term, Context: t.Context}]
+			if !ok {
+				content = poedit.TranslationContent{}
+			}
+
+			fuzzy := 0
+			if l.Fuzzy[poedit.TermKey{Term: t.Term.Term, Context: t.Context}] {
+				fuzzy = 1
+			}
+
+			term["translation"] = map[string]interface{}{
+				"content":   content,
+				"fuzzy":     fuzzy,
+				"proofread": 0,
+				"updated":   formatTime(l.Updated),
+			}
+		}
+
+		terms = append(terms, term)
+	}
+
+	return CodeOK, "OK", map[string]interface{}{"terms": terms}
+}
+
+func (s *Server) addTerms(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	var terms []poedit.Term
+	if err := json.Unmarshal([]byte(r.PostForm.Get("data")), &terms); err != nil {
+		return "4032", "Invalid data", nil
+	}
+
+	added := 0
+	for _, t := range terms {
+		if findTerm(p, poedit.TermKey{Term: t.Term, Context: t.Context}) >= 0 {
+			continue
+		}
+
+		s.addTerm(p, t)
+		added++
+	}
+
+	return CodeOK, "OK", map[string]interface{}{
+		"terms": map[string]int{"parsed": len(terms), "added": added},
+	}
+}
+
+func (s *Server) updateTerms(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	var updates []poedit.TermUpdate
+	if err := json.Unmarshal([]byte(r.PostForm.Get("data")), &updates); err != nil {
+		return "4032", "Invalid data", nil
+	}
+
+	updated := 0
+	for _, u := range updates {
+		key := poedit.TermKey{Term: u.Term, Context: u.Context}
+
+		i := findTerm(p, key)
+		if i < 0 {
+			continue
+		}
+
+		t := p.Terms[i]
+		newKey := key
+
+		if u.NewTerm != "" {
+			newKey.Term = u.NewTerm
+		}
+
+		if u.NewContext != "" {
+			newKey.Context = u.NewContext
+		}
+
+		t.Term = poedit.Term{
+			Term:      newKey.Term,
+			Context:   newKey.Context,
+			Reference: u.Reference,
+			Plural:    u.Plural,
+			Comment:   u.Comment,
+			Tags:      u.Tags,
+		}
+		t.Updated = time.Now()
+
+		if newKey != key {
+			for _, l := range p.Languages {
+				if content, ok := l.Translations[key]; ok {
+					delete(l.Translations, key)
+					l.Translations[newKey] = content
+				}
+
+				if l.Fuzzy[key] {
+					delete(l.Fuzzy, key)
+					l.Fuzzy[newKey] = true
+				}
+			}
+		}
+
+		updated++
+	}
+
+	return CodeOK, "OK", map[string]interface{}{
+		"terms": map[string]int{"parsed": len(updates), "updated": updated},
+	}
+}
+
+func (s *Server) deleteTerms(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	var keys []poedit.TermKey
+	if err := json.Unmarshal([]byte(r.PostForm.Get("data")), &keys); err != nil {
+		return "4032", "Invalid data", nil
+	}
+
+	remove := map[poedit.TermKey]bool{}
+	for _, k := range keys {
+		remove[k] = true
+	}
+
+	deleted := s.removeTerms(p, func(key poedit.TermKey) bool {
+		return remove[key]
+	})
+
+	return CodeOK, "OK", map[string]interface{}{
+		"terms": map[string]int{"parsed": len(keys), "deleted": deleted},
+	}
+}
+
+// uploadedTerm is an entry of an uploaded file, in the json format of poeditor.
+type uploadedTerm struct {
+	Term       string                     `json:"term"`
+	Definition *poedit.TranslationContent `json:"definition"`
+	Context    string                     `json:"context"`
+	Plural     string                     `json:"term_plural"`
+	Reference  string                     `json:"reference"`
+	Comment    string                     `json:"comment"`
+}
+
+// uploadProject imports an uploaded file into a project, like poeditor does for uploads of its json format.
+// Other formats are rejected, and the tags, read_from_source and fuzzy_trigger options are ignored.
+func (s *Server) uploadProject(r *http.Request) (string, string, interface{}) {
+	if s.uploadInterval > 0 && time.Since(s.lastUpload) < s.uploadInterval {
+		return CodeUploadThrottled, "Too many upload requests in a short period of time", nil
+	}
+
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	updating := r.PostForm.Get("updating")
+	if updating != poedit.UpdatingTerms && updating != poedit.UpdatingTermsTranslations && updating != poedit.UpdatingTranslations {
+		return "4032", "Invalid updating value", nil
+	}
+
+	var l *Language
+	if updating != poedit.UpdatingTerms {
+		var ok bool
+		if l, ok = p.Languages[r.PostForm.Get("language")]; !ok {
+			return CodeLanguageNotFound, "Language not found in project", nil
+		}
+	}
+
+	f, _, err := r.FormFile("file")
+	if err != nil {
+		return "4032", "No file uploaded", nil
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return "4032", "Failed to read uploaded file", nil
+	}
+
+	var entries []uploadedTerm
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return "4032", "Unsupported file, only the json format of poeditor is supported", nil
+	}
+
+	s.lastUpload = time.Now()
+
+	terms := map[string]int{"parsed": 0, "added": 0, "deleted": 0}
+	translations := map[string]int{"parsed": 0, "added": 0, "updated": 0}
+
+	if updating != poedit.UpdatingTranslations {
+		keep := map[poedit.TermKey]bool{}
+
+		for _, e := range entries {
+			key := poedit.TermKey{Term: e.Term, Context: e.Context}
+			keep[key] = true
+			terms["parsed"]++
+
+			if findTerm(p, key) >= 0 {
+				continue
+			}
+
+			s.addTerm(p, poedit.Term{
+				Term:      e.Term,
+				Context:   e.Context,
+				Reference: e.Reference,
+				Plural:    e.Plural,
+				Comment:   e.Comment,
+			})
+			terms["added"]++
+		}
+
+		if r.PostForm.Get("sync_terms") == "1" {
+			terms["deleted"] = s.removeTerms(p, func(key poedit.TermKey) bool {
+				return !keep[key]
+			})
+		}
+	}
+
+	if l != nil {
+		overwrite := r.PostForm.Get("overwrite") == "1"
+
+		for _, e := range entries {
+			key := poedit.TermKey{Term: e.Term, Context: e.Context}
+			if e.Definition == nil || !translated(*e.Definition) || findTerm(p, key) < 0 {
+				continue
+			}
+
+			translations["parsed"]++
+
+			if _, ok := l.Translations[key]; !ok {
+				translations["added"]++
+			} else if overwrite {
+				translations["updated"]++
+			} else {
+				continue
+			}
+
+			l.Translations[key] = *e.Definition
+			l.Updated = time.Now()
+		}
+	}
+
+	return CodeOK, "OK", map[string]interface{}{
+		"terms":        terms,
+		"translations": translations,
+	}
+}
+
+// requestTranslations returns the language and the translations of a request, or the response code if they are invalid.
+func (s *Server) requestTranslations(r *http.Request) (*Project, *Language, []poedit.Translation, string, string) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return nil, nil, nil, code, message
+	}
+
+	l, ok := p.Languages[r.PostForm.Get("language")]
+	if !ok {
+		return nil, nil, nil, CodeLanguageNotFound, "Language not found in project"
+	}
+
+	var translations []poedit.Translation
+	if err := json.Unmarshal([]byte(r.PostForm.Get("data")), &translations); err != nil {
+		return nil, nil, nil, "4032", "Invalid data"
+	}
+
+	return p, l, translations, CodeOK, ""
+}
+
+func (s *Server) addTranslations(r *http.Request) (string, string, interface{}) {
+	p, l, translations, code, message := s.requestTranslations(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	added := 0
+	for _, t := range translations {
+		key := poedit.TermKey{Term: t.Term, Context: t.Context}
+		if findTerm(p, key) < 0 {
+			continue
+		}
+
+		if _, ok := l.Translations[key]; ok {
+			continue
+		}
+
+		l.Translations[key] = t.Translation.Content
+		setFuzzy(l, key, t.Translation.Fuzzy == 1)
+		l.Updated = time.Now()
+		added++
+	}
+
+	return CodeOK, "OK", map[string]interface{}{
+		"translations": map[string]int{"parsed": len(translations), "added": added},
+	}
+}
+
+// updateTranslations updates the translations of a language. When fuzzy_trigger is set,
+// the translations of the updated terms in the other languages are marked as fuzzy.
+func (s *Server) updateTranslations(r *http.Request) (string, string, interface{}) {
+	p, l, translations, code, message := s.requestTranslations(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	updated := 0
+	for _, t := range translations {
+		key := poedit.TermKey{Term: t.Term, Context: t.Context}
+		if findTerm(p, key) < 0 {
+			continue
+		}
+
+		l.Translations[key] = t.Translation.Content
+		setFuzzy(l, key, t.Translation.Fuzzy == 1)
+		l.Updated = time.Now()
+		updated++
+
+		if r.PostForm.Get("fuzzy_trigger") != "1" {
+			continue
+		}
+
+		for _, other := range p.Languages {
+			if _, ok := other.Translations[key]; ok && other != l {
+				setFuzzy(other, key, true)
+				other.Updated = time.Now()
+			}
+		}
+	}
+
+	return CodeOK, "OK", map[string]interface{}{
+		"translations": map[string]int{"parsed": len(translations), "updated": updated},
+	}
+}
+
+func (s *Server) deleteTranslations(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	l, ok := p.Languages[r.PostForm.Get("language")]
+	if !ok {
+		return CodeLanguageNotFound, "Language not found in project", nil
+	}
+
+	var keys []poedit.TermKey
+	if err := json.Unmarshal([]byte(r.PostForm.Get("data")), &keys); err != nil {
+		return "4032", "Invalid data", nil
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if _, ok := l.Translations[key]; !ok {
+			continue
+		}
+
+		delete(l.Translations, key)
+		delete(l.Fuzzy, key)
+		l.Updated = time.Now()
+		deleted++
+	}
+
+	return CodeOK, "OK", map[string]interface{}{
+		"translations": map[string]int{"parsed": len(keys), "deleted": deleted},
+	}
+}
+
+// listContributors lists the contributors of every project, or of the project and language of the request.
+// A contributor of several projects is listed once, with a permission per project.
+func (s *Server) listContributors(r *http.Request) (string, string, interface{}) {
+	ids := []int{}
+
+	if r.PostForm.Get("id") != "" {
+		p, code, message := s.requestProject(r)
+		if p == nil {
+			return code, message, nil
+		}
+
+		ids = append(ids, p.ID)
+	} else {
+		for id := range s.projects {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+	}
+
+	language := r.PostForm.Get("language")
+
+	emails := []string{}
+	contributors := map[string]map[string]interface{}{}
+
+	for _, id := range ids {
+		p := s.projects[id]
+
+		for _, c := range p.Contributors {
+			if language != "" && c.Role != poedit.RoleAdministrator && !contains(c.Languages, language) {
+				continue
+			}
+
+			contributor, ok := contributors[c.Email]
+			if !ok {
+				contributor = map[string]interface{}{
+					"name":        c.Name,
+					"email":       c.Email,
+					"permissions": []interface{}{},
+				}
+				contributors[c.Email] = contributor
+				emails = append(emails, c.Email)
+			}
+
+			languages := c.Languages
+			if languages == nil {
+				languages = []string{}
+			}
+
+			contributor["permissions"] = append(contributor["permissions"].([]interface{}), map[string]interface{}{
+				"project": map[string]string{
+					"id":   strconv.Itoa(p.ID),
+					"name": p.Name,
+				},
+				"type":        c.Role,
+				"proofreader": c.Proofreader,
+				"languages":   languages,
+			})
+		}
+	}
+
+	sort.Strings(emails)
+
+	result := make([]interface{}, 0, len(emails))
+	for _, email := range emails {
+		result = append(result, contributors[email])
+	}
+
+	return CodeOK, "OK", map[string]interface{}{"contributors": result}
+}
+
+func (s *Server) addContributor(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	email := r.PostForm.Get("email")
+	if email == "" {
+		return "4032", "Invalid email", nil
+	}
+
+	c, ok := p.Contributors[email]
+	if !ok {
+		c = &Contributor{
+			Name:  r.PostForm.Get("name"),
+			Email: email,
+			Role:  poedit.RoleContributor,
+		}
+	}
+
+	if r.PostForm.Get("admin") == "1" {
+		c.Role = poedit.RoleAdministrator
+		c.Languages = nil
+	} else {
+		language := r.PostForm.Get("language")
+		if _, ok := p.Languages[language]; !ok {
+			return CodeLanguageNotFound, "Language not found in project", nil
+		}
+
+		if !contains(c.Languages, language) {
+			c.Languages = append(c.Languages, language)
+		}
+
+		c.Proofreader = r.PostForm.Get("proofreader") == "1"
+	}
+
+	p.Contributors[email] = c
+
+	return CodeOK, "Contributor added", nil
+}
+
+// removeContributor removes a contributor from a project, or only from the language of the request.
+// Removing a contributor which is not in the project succeeds without changes.
+func (s *Server) removeContributor(r *http.Request) (string, string, interface{}) {
+	p, code, message := s.requestProject(r)
+	if p == nil {
+		return code, message, nil
+	}
+
+	email := r.PostForm.Get("email")
+
+	c, ok := p.Contributors[email]
+	if !ok {
+		return CodeOK, "Contributor removed", nil
+	}
+
+	language := r.PostForm.Get("language")
+	if language == "" {
+		delete(p.Contributors, email)
+
+		return CodeOK, "Contributor removed", nil
+	}
+
+	languages := c.Languages[:0]
+	for _, l := range c.Languages {
+		if l != language {
+			languages = append(languages, l)
+		}
+	}
+	c.Languages = languages
+
+	if len(c.Languages) == 0 && c.Role != poedit.RoleAdministrator {
+		delete(p.Contributors, email)
+	}
+
+	return CodeOK, "Contributor removed", nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
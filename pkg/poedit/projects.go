@@ -1,6 +1,7 @@
 package poedit
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"gopkg.in/resty.v1"
 )
 
 type ListProjectsResponse struct {
@@ -31,13 +33,7 @@ type ListProjectsResponse struct {
 //
 // https://poeditor.com/docs/api#projects_list
 func (c *ClientImpl) ListProjects(ctx context.Context) (*ListProjectsResponse, error) {
-	req := c.r.R()
-
-	req.SetContext(ctx)
-
-	req.SetResult(&ListProjectsResponse{})
-
-	resp, err := req.Post("/v2/projects/list")
+	resp, err := c.query(ctx, "/v2/projects/list", nil, &ListProjectsResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -47,8 +43,8 @@ func (c *ClientImpl) ListProjects(ctx context.Context) (*ListProjectsResponse, e
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, 0, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -83,17 +79,9 @@ type ViewProjectResponse struct {
 //
 // https://poeditor.com/docs/api#projects_view
 func (c *ClientImpl) ViewProject(ctx context.Context, r ViewProjectRequest) (*ViewProjectResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.query(ctx, "/v2/projects/view", map[string]string{
 		"id": fmt.Sprintf("%d", r.ID),
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&ViewProjectResponse{})
-
-	resp, err := req.Post("/v2/projects/view")
+	}, &ViewProjectResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -103,8 +91,8 @@ func (c *ClientImpl) ViewProject(ctx context.Context, r ViewProjectRequest) (*Vi
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -139,18 +127,10 @@ type AddProjectResponse struct {
 //
 // https://poeditor.com/docs/api#projects_add
 func (c *ClientImpl) AddProject(ctx context.Context, r AddProjectRequest) (*AddProjectResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.post(ctx, "/v2/projects/add", map[string]string{
 		"name":        r.Name,
 		"description": r.Description,
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&AddProjectResponse{})
-
-	resp, err := req.Post("/v2/projects/add")
+	}, &AddProjectResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -160,8 +140,8 @@ func (c *ClientImpl) AddProject(ctx context.Context, r AddProjectRequest) (*AddP
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, 0, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -199,20 +179,12 @@ type UpdateProjectSettingsResponse struct {
 //
 // https://poeditor.com/docs/api#projects_update
 func (c *ClientImpl) UpdateProjectSettings(ctx context.Context, r UpdateProjectSettingsRequest) (*UpdateProjectSettingsResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.post(ctx, "/v2/projects/update", map[string]string{
 		"id":                 fmt.Sprintf("%d", r.ID),
 		"name":               r.Name,
 		"description":        r.Description,
 		"reference_language": r.ReferenceLanguage,
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&UpdateProjectSettingsResponse{})
-
-	resp, err := req.Post("/v2/projects/update")
+	}, &UpdateProjectSettingsResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -222,8 +194,8 @@ func (c *ClientImpl) UpdateProjectSettings(ctx context.Context, r UpdateProjectS
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -246,17 +218,9 @@ type DeleteProjectResponse struct {
 //
 // https://poeditor.com/docs/api#projects_delete
 func (c *ClientImpl) DeleteProject(ctx context.Context, r DeleteProjectRequest) (*DeleteProjectResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.post(ctx, "/v2/projects/delete", map[string]string{
 		"id": fmt.Sprintf("%d", r.ID),
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&DeleteProjectResponse{})
-
-	resp, err := req.Post("/v2/projects/delete")
+	}, &DeleteProjectResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -266,17 +230,26 @@ func (c *ClientImpl) DeleteProject(ctx context.Context, r DeleteProjectRequest)
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
 }
 
+// Values of UploadProjectRequest.Updating
+const (
+	UpdatingTerms             = "terms"
+	UpdatingTermsTranslations = "terms_translations"
+	UpdatingTranslations      = "translations"
+)
+
 type UploadProjectRequest struct {
-	ID             int
-	Updating       string
-	File           io.Reader
+	ID       int
+	Updating string
+	File     io.Reader
+	// FileName is the name of the uploaded file. Poeditor detects the format of the file by its extension.
+	FileName       string
 	Language       string
 	Overwrite      bool
 	SyncTerms      bool
@@ -306,12 +279,59 @@ type UploadProjectResponse struct {
 }
 
 // UploadProject Updates terms / translations - No more than one request every 30 seconds.
+// Uploads are queued by the client until their turn, unless disabled with WithUploadInterval.
 //
 // https://poeditor.com/docs/api#projects_upload
-//
-// NOT IMPLEMENTED In this sdk
 func (c *ClientImpl) UploadProject(ctx context.Context, r UploadProjectRequest) (*UploadProjectResponse, error) {
-	return nil, ErrNotImplemented
+	if r.File == nil {
+		return nil, errors.New("No file to upload")
+	}
+
+	// Read the file before queueing, such that a slow or failing reader does not hold the upload slot.
+	// Uploads are never retried, as poeditor may have applied an upload even if the request failed.
+	file, err := io.ReadAll(r.File)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read file to upload")
+	}
+
+	form := map[string]string{
+		"id":               fmt.Sprintf("%d", r.ID),
+		"updating":         r.Updating,
+		"language":         r.Language,
+		"overwrite":        formDataBool(r.Overwrite),
+		"sync_terms":       formDataBool(r.SyncTerms),
+		"read_from_source": formDataBool(r.ReadFromSource),
+		"fuzzy_trigger":    formDataBool(r.FuzzyTrigger),
+	}
+
+	if r.Tags != "" {
+		form["tags"] = r.Tags
+	}
+
+	done, err := c.uploads.wait(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	resp, err := c.send(ctx, "/v2/projects/upload", &UploadProjectResponse{}, 0, func(req *resty.Request) {
+		req.SetFormData(form)
+		req.SetFileReader("file", r.FileName, bytes.NewReader(file))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*UploadProjectResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
 }
 
 type SyncProjectTermsRequest struct {
@@ -347,23 +367,15 @@ type SyncProjectTermsResponse struct {
 //
 // https://poeditor.com/docs/api#projects_sync
 func (c *ClientImpl) SyncProjectTerms(ctx context.Context, r SyncProjectTermsRequest) (*SyncProjectTermsResponse, error) {
-	req := c.r.R()
-
 	data, err := json.Marshal(r.Data)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to marshal data")
 	}
 
-	req.SetFormData(map[string]string{
+	resp, err := c.post(ctx, "/v2/projects/sync", map[string]string{
 		"id":   fmt.Sprintf("%d", r.ID),
 		"data": string(data),
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&SyncProjectTermsResponse{})
-
-	resp, err := req.Post("/v2/projects/sync")
+	}, &SyncProjectTermsResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -373,8 +385,8 @@ func (c *ClientImpl) SyncProjectTerms(ctx context.Context, r SyncProjectTermsReq
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
 	}
 
 	return res, nil
@@ -405,9 +417,7 @@ type ExportProjectResponse struct {
 //
 // https://poeditor.com/docs/api#projects_export
 func (c *ClientImpl) ExportProject(ctx context.Context, r ExportProjectRequest) (*ExportProjectResponse, error) {
-	req := c.r.R()
-
-	req.SetFormData(map[string]string{
+	resp, err := c.query(ctx, "/v2/projects/export", map[string]string{
 		"id":       fmt.Sprintf("%d", r.ID),
 		"language": r.Language,
 		"type":     r.Type,
@@ -415,13 +425,7 @@ func (c *ClientImpl) ExportProject(ctx context.Context, r ExportProjectRequest)
 		"tags":     formDataArray(r.Tags),
 		"filters":  formDataArray(r.Filters),
 		"options":  formDataArray(r.Options),
-	})
-
-	req.SetContext(ctx)
-
-	req.SetResult(&ExportProjectResponse{})
-
-	resp, err := req.Post("/v2/projects/export")
+	}, &ExportProjectResponse{})
 	if err != nil {
 		return nil, err
 	}
@@ -431,24 +435,19 @@ func (c *ClientImpl) ExportProject(ctx context.Context, r ExportProjectRequest)
 		return nil, ErrFailedToUnmarshalResponse
 	}
 
-	if res.Response.Code == "403" {
-		return nil, &ErrProjectPermissionDenied{
-			ProjectID: r.ID,
-		}
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
 	}
 
-	if res.Response.Code == "4044" {
-		return nil, &ErrLanguageNotFound{
-			ProjectID:    r.ID,
-			LanguageCode: r.Language,
-		}
-	}
+	return res, nil
+}
 
-	if res.Response.Code != "200" {
-		return nil, errors.New(res.Response.Message)
+func formDataBool(b bool) string {
+	if b {
+		return "1"
 	}
 
-	return res, nil
+	return "0"
 }
 
 func formDataArray(data []string) string {
@@ -0,0 +1,340 @@
+package poedit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type uploadRecorder struct {
+	mutex    *sync.Mutex
+	requests []recordedUpload
+	code     string
+	status   int
+}
+
+type recordedUpload struct {
+	at       time.Time
+	form     map[string]string
+	fileName string
+	file     string
+}
+
+func newUploadServer(t *testing.T, code string, status int) (*httptest.Server, *uploadRecorder) {
+	t.Helper()
+
+	rec := &uploadRecorder{
+		mutex:  &sync.Mutex{},
+		code:   code,
+		status: status,
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/projects/upload" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("Failed to parse upload form: %v", err)
+		}
+
+		upload := recordedUpload{
+			at:   time.Now(),
+			form: map[string]string{},
+		}
+
+		for key := range r.MultipartForm.Value {
+			upload.form[key] = r.FormValue(key)
+		}
+
+		if f, header, err := r.FormFile("file"); err == nil {
+			b, _ := io.ReadAll(f)
+			upload.fileName = header.Filename
+			upload.file = string(b)
+		}
+
+		rec.mutex.Lock()
+		rec.requests = append(rec.requests, upload)
+		rec.mutex.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(rec.status)
+
+		res := UploadProjectResponse{}
+		res.Response.Code = rec.code
+		res.Response.Message = "message"
+		res.Result.Terms.Added = 2
+
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, rec
+}
+
+func (r *uploadRecorder) uploads() []recordedUpload {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]recordedUpload{}, r.requests...)
+}
+
+func TestUploadProjectSendsForm(t *testing.T) {
+	srv, rec := newUploadServer(t, "200", http.StatusOK)
+
+	c := NewClient("token", srv.Client(), WithHostURL(srv.URL), WithUploadInterval(0))
+
+	res, err := c.UploadProject(context.Background(), UploadProjectRequest{
+		ID:        7,
+		Updating:  UpdatingTermsTranslations,
+		File:      strings.NewReader(`{"hello":"Hej"}`),
+		FileName:  "da.json",
+		Language:  "da",
+		Overwrite: true,
+		SyncTerms: true,
+		Tags:      `["restored"]`,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if res.Result.Terms.Added != 2 {
+		t.Errorf("Expected 2 added terms, got %d", res.Result.Terms.Added)
+	}
+
+	uploads := rec.uploads()
+	if len(uploads) != 1 {
+		t.Fatalf("Expected 1 upload, got %d", len(uploads))
+	}
+
+	expected := map[string]string{
+		"api_token":        "token",
+		"id":               "7",
+		"updating":         "terms_translations",
+		"language":         "da",
+		"overwrite":        "1",
+		"sync_terms":       "1",
+		"read_from_source": "0",
+		"fuzzy_trigger":    "0",
+		"tags":             `["restored"]`,
+	}
+	for key, value := range expected {
+		if uploads[0].form[key] != value {
+			t.Errorf("Expected form field %s to be %q, got %q", key, value, uploads[0].form[key])
+		}
+	}
+
+	if uploads[0].fileName != "da.json" || uploads[0].file != `{"hello":"Hej"}` {
+		t.Errorf("Unexpected file %q with content %q", uploads[0].fileName, uploads[0].file)
+	}
+}
+
+func TestUploadProjectDefaults(t *testing.T) {
+	srv, rec := newUploadServer(t, "200", http.StatusOK)
+
+	c := NewClient("token", srv.Client(), WithHostURL(srv.URL), WithUploadInterval(0))
+
+	_, err := c.UploadProject(context.Background(), UploadProjectRequest{
+		ID:       7,
+		Updating: UpdatingTerms,
+		File:     strings.NewReader(`{}`),
+		FileName: "terms.json",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	form := rec.uploads()[0].form
+
+	if form["overwrite"] != "0" || form["sync_terms"] != "0" {
+		t.Errorf("Expected overwrite and sync_terms to be off, got %q and %q", form["overwrite"], form["sync_terms"])
+	}
+
+	if _, ok := form["tags"]; ok {
+		t.Errorf("Expected no tags, got %q", form["tags"])
+	}
+}
+
+func TestUploadProjectWithoutFile(t *testing.T) {
+	c := NewClient("token", http.DefaultClient, WithHostURL("http://127.0.0.1:0"), WithUploadInterval(0))
+
+	if _, err := c.UploadProject(context.Background(), UploadProjectRequest{ID: 7}); err == nil {
+		t.Error("Expected an error when uploading without a file")
+	}
+}
+
+func TestUploadProjectErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		code   string
+		status int
+		check  func(err error) bool
+	}{
+		{
+			name:   "throttled",
+			code:   "4048",
+			status: http.StatusOK,
+			check: func(err error) bool {
+				return errors.Is(err, ErrUploadThrottled)
+			},
+		},
+		{
+			name:   "invalid token",
+			code:   "4010",
+			status: http.StatusOK,
+			check: func(err error) bool {
+				return errors.Is(err, ErrInvalidToken)
+			},
+		},
+		{
+			name:   "project not found",
+			code:   "4012",
+			status: http.StatusOK,
+			check: func(err error) bool {
+				var notFound *ErrProjectNotFound
+
+				return errors.As(err, &notFound) && notFound.ProjectID == 7
+			},
+		},
+		{
+			name:   "language not found",
+			code:   "4044",
+			status: http.StatusOK,
+			check: func(err error) bool {
+				var notFound *ErrLanguageNotFound
+
+				return errors.As(err, &notFound) && notFound.LanguageCode == "da"
+			},
+		},
+		{
+			name:   "unknown code",
+			code:   "4999",
+			status: http.StatusOK,
+			check: func(err error) bool {
+				var apiErr *ErrAPI
+
+				return errors.As(err, &apiErr) && apiErr.Code == "4999"
+			},
+		},
+		{
+			name:   "unavailable",
+			code:   "",
+			status: http.StatusBadGateway,
+			check: func(err error) bool {
+				var unavailable *ErrUnavailable
+
+				return errors.As(err, &unavailable)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			srv, rec := newUploadServer(t, tt.code, tt.status)
+
+			c := NewClient("token", srv.Client(),
+				WithHostURL(srv.URL),
+				WithUploadInterval(0),
+				WithRetries(3, time.Millisecond, time.Millisecond),
+			)
+
+			_, err := c.UploadProject(context.Background(), UploadProjectRequest{
+				ID:       7,
+				Updating: UpdatingTranslations,
+				File:     strings.NewReader(`{}`),
+				FileName: "da.json",
+				Language: "da",
+			})
+			if err == nil || !tt.check(err) {
+				t.Errorf("Unexpected error: %v", err)
+			}
+
+			if n := len(rec.uploads()); n != 1 {
+				t.Errorf("Expected the upload to be sent once, got %d", n)
+			}
+		})
+	}
+}
+
+func TestUploadProjectThrottle(t *testing.T) {
+	srv, rec := newUploadServer(t, "200", http.StatusOK)
+
+	interval := time.Millisecond * 200
+
+	c := NewClient("token", srv.Client(), WithHostURL(srv.URL), WithUploadInterval(interval))
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.UploadProject(context.Background(), UploadProjectRequest{
+				ID:       7,
+				Updating: UpdatingTerms,
+				File:     strings.NewReader(`{}`),
+				FileName: "terms.json",
+			})
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	uploads := rec.uploads()
+	if len(uploads) != 3 {
+		t.Fatalf("Expected 3 uploads, got %d", len(uploads))
+	}
+
+	for i := 1; i < len(uploads); i++ {
+		if gap := uploads[i].at.Sub(uploads[i-1].at); gap < interval {
+			t.Errorf("Expected uploads to be at least %s apart, upload %d followed after %s", interval, i, gap)
+		}
+	}
+}
+
+func TestUploadProjectThrottleCancelled(t *testing.T) {
+	srv, rec := newUploadServer(t, "200", http.StatusOK)
+
+	c := NewClient("token", srv.Client(), WithHostURL(srv.URL), WithUploadInterval(time.Hour))
+
+	req := func(ctx context.Context) error {
+		_, err := c.UploadProject(ctx, UploadProjectRequest{
+			ID:       7,
+			Updating: UpdatingTerms,
+			File:     strings.NewReader(`{}`),
+			FileName: "terms.json",
+		})
+
+		return err
+	}
+
+	if err := req(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	if err := req(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the queued upload to give up with its context, got %v", err)
+	}
+
+	if n := len(rec.uploads()); n != 1 {
+		t.Errorf("Expected only the first upload to be sent, got %d", n)
+	}
+}
@@ -0,0 +1,329 @@
+package poedit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Term is a term of a project, as it is sent to the poeditor api.
+type Term struct {
+	Term      string   `json:"term"`
+	Context   string   `json:"context"`
+	Reference string   `json:"reference,omitempty"`
+	Plural    string   `json:"plural,omitempty"`
+	Comment   string   `json:"comment,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// TermKey identifies a term of a project. Terms are unique by the combination of term and context.
+type TermKey struct {
+	Term    string `json:"term"`
+	Context string `json:"context"`
+}
+
+// TranslationContent is the content of a translation.
+// Translations of terms with a plural are keyed by plural form, such as "one" and "other".
+type TranslationContent struct {
+	Singular string
+	Plural   map[string]string
+}
+
+func (t *TranslationContent) UnmarshalJSON(b []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(b), []byte("{")) {
+		t.Singular = ""
+
+		return json.Unmarshal(b, &t.Plural)
+	}
+
+	t.Plural = nil
+
+	return json.Unmarshal(b, &t.Singular)
+}
+
+func (t TranslationContent) MarshalJSON() ([]byte, error) {
+	if t.Plural != nil {
+		return json.Marshal(t.Plural)
+	}
+
+	return json.Marshal(t.Singular)
+}
+
+type ListTermsRequest struct {
+	ID int
+	// Language is optional. If set, the translation of each term in the language is returned as well.
+	Language string
+}
+
+type ListTermsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Terms []struct {
+			Term        string   `json:"term"`
+			Context     string   `json:"context"`
+			Plural      string   `json:"plural"`
+			Created     string   `json:"created"`
+			Updated     string   `json:"updated"`
+			Reference   string   `json:"reference"`
+			Tags        []string `json:"tags"`
+			Comment     string   `json:"comment"`
+			Translation struct {
+				Content   TranslationContent `json:"content"`
+				Fuzzy     int64              `json:"fuzzy"`
+				Proofread int64              `json:"proofread"`
+				Updated   string             `json:"updated"`
+			} `json:"translation"`
+		} `json:"terms"`
+	} `json:"result"`
+}
+
+// ListTerms Returns project's terms and translations if the argument language is provided.
+//
+// https://poeditor.com/docs/api#terms_list
+func (c *ClientImpl) ListTerms(ctx context.Context, r ListTermsRequest) (*ListTermsResponse, error) {
+	form := map[string]string{
+		"id": fmt.Sprintf("%d", r.ID),
+	}
+
+	if r.Language != "" {
+		form["language"] = r.Language
+	}
+
+	resp, err := c.query(ctx, "/v2/terms/list", form, &ListTermsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*ListTermsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type AddTermsRequest struct {
+	ID    int
+	Terms []Term
+}
+
+type AddTermsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Terms struct {
+			Parsed int64 `json:"parsed"`
+			Added  int64 `json:"added"`
+		} `json:"terms"`
+	} `json:"result"`
+}
+
+// AddTerms Adds terms to project.
+//
+// https://poeditor.com/docs/api#terms_add
+func (c *ClientImpl) AddTerms(ctx context.Context, r AddTermsRequest) (*AddTermsResponse, error) {
+	data, err := json.Marshal(r.Terms)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal data")
+	}
+
+	resp, err := c.post(ctx, "/v2/terms/add", map[string]string{
+		"id":   fmt.Sprintf("%d", r.ID),
+		"data": string(data),
+	}, &AddTermsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*AddTermsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// TermUpdate is an update of a term, identified by its current term and context.
+// NewTerm and NewContext are optional, and rename the term when set.
+type TermUpdate struct {
+	Term       string   `json:"term"`
+	Context    string   `json:"context"`
+	NewTerm    string   `json:"new_term,omitempty"`    // nolint:tagliatelle
+	NewContext string   `json:"new_context,omitempty"` // nolint:tagliatelle
+	Reference  string   `json:"reference,omitempty"`
+	Plural     string   `json:"plural,omitempty"`
+	Comment    string   `json:"comment,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+type UpdateTermsRequest struct {
+	ID    int
+	Terms []TermUpdate
+	// FuzzyTrigger marks the translations of the updated terms as fuzzy in every language.
+	FuzzyTrigger bool
+}
+
+type UpdateTermsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Terms struct {
+			Parsed  int64 `json:"parsed"`
+			Updated int64 `json:"updated"`
+		} `json:"terms"`
+	} `json:"result"`
+}
+
+// UpdateTerms Updates project terms. Lets you change the text, context, reference, plural and tags.
+//
+// https://poeditor.com/docs/api#terms_update
+func (c *ClientImpl) UpdateTerms(ctx context.Context, r UpdateTermsRequest) (*UpdateTermsResponse, error) {
+	data, err := json.Marshal(r.Terms)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal data")
+	}
+
+	resp, err := c.post(ctx, "/v2/terms/update", map[string]string{
+		"id":            fmt.Sprintf("%d", r.ID),
+		"fuzzy_trigger": formDataBool(r.FuzzyTrigger),
+		"data":          string(data),
+	}, &UpdateTermsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*UpdateTermsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type DeleteTermsRequest struct {
+	ID    int
+	Terms []TermKey
+}
+
+type DeleteTermsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Terms struct {
+			Parsed  int64 `json:"parsed"`
+			Deleted int64 `json:"deleted"`
+		} `json:"terms"`
+	} `json:"result"`
+}
+
+// DeleteTerms Deletes terms from project, along with their translations.
+//
+// https://poeditor.com/docs/api#terms_delete
+func (c *ClientImpl) DeleteTerms(ctx context.Context, r DeleteTermsRequest) (*DeleteTermsResponse, error) {
+	data, err := json.Marshal(r.Terms)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal data")
+	}
+
+	resp, err := c.post(ctx, "/v2/terms/delete", map[string]string{
+		"id":   fmt.Sprintf("%d", r.ID),
+		"data": string(data),
+	}, &DeleteTermsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*DeleteTermsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// TermComment is a comment to add to a term.
+type TermComment struct {
+	Term    string `json:"term"`
+	Context string `json:"context"`
+	Comment string `json:"comment"`
+}
+
+type AddTermCommentRequest struct {
+	ID       int
+	Comments []TermComment
+}
+
+type AddTermCommentResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Terms struct {
+			Parsed           int64 `json:"parsed"`
+			WithAddedComment int64 `json:"with_added_comment"` // nolint:tagliatelle
+		} `json:"terms"`
+	} `json:"result"`
+}
+
+// AddTermComment Adds comments to existing terms.
+//
+// https://poeditor.com/docs/api#terms_add_comment
+func (c *ClientImpl) AddTermComment(ctx context.Context, r AddTermCommentRequest) (*AddTermCommentResponse, error) {
+	data, err := json.Marshal(r.Comments)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal data")
+	}
+
+	resp, err := c.post(ctx, "/v2/terms/add_comment", map[string]string{
+		"id":   fmt.Sprintf("%d", r.ID),
+		"data": string(data),
+	}, &AddTermCommentResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*AddTermCommentResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -0,0 +1,25 @@
+package poedit
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TimeLayout is the layout of datetimes returned by the poeditor api (UTC - ISO 8601).
+const TimeLayout = "2006-01-02T15:04:05-0700"
+
+// ParseTime parses a datetime as returned by the poeditor api.
+// An empty string, which poeditor uses for "never", results in the zero time.
+func ParseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(TimeLayout, s)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "Failed to parse poeditor time '%s'", s)
+	}
+
+	return t, nil
+}
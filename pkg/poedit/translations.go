@@ -0,0 +1,189 @@
+package poedit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// Translation is the translation of a term, as it is sent to the poeditor api.
+// Content holds plural forms, such as "one" and "other", for terms with a plural.
+type Translation struct {
+	Term        string `json:"term"`
+	Context     string `json:"context"`
+	Translation struct {
+		Content TranslationContent `json:"content"`
+		Fuzzy   int64              `json:"fuzzy"`
+	} `json:"translation"`
+}
+
+// NewTranslation creates the translation of a term, optionally marked as fuzzy.
+func NewTranslation(term, termContext string, content TranslationContent, fuzzy bool) Translation {
+	t := Translation{
+		Term:    term,
+		Context: termContext,
+	}
+
+	t.Translation.Content = content
+
+	if fuzzy {
+		t.Translation.Fuzzy = 1
+	}
+
+	return t
+}
+
+type AddTranslationsRequest struct {
+	ID           int
+	Language     string
+	Translations []Translation
+}
+
+type AddTranslationsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Translations struct {
+			Parsed int64 `json:"parsed"`
+			Added  int64 `json:"added"`
+		} `json:"translations"`
+	} `json:"result"`
+}
+
+// AddTranslations Adds translations to a language. Existing translations are not overwritten.
+//
+// https://poeditor.com/docs/api#translations_add
+func (c *ClientImpl) AddTranslations(ctx context.Context, r AddTranslationsRequest) (*AddTranslationsResponse, error) {
+	data, err := json.Marshal(r.Translations)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal data")
+	}
+
+	resp, err := c.post(ctx, "/v2/translations/add", map[string]string{
+		"id":       fmt.Sprintf("%d", r.ID),
+		"language": r.Language,
+		"data":     string(data),
+	}, &AddTranslationsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*AddTranslationsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type UpdateTranslationsRequest struct {
+	ID           int
+	Language     string
+	Translations []Translation
+	// FuzzyTrigger marks the translations of the updated terms as fuzzy in the other languages.
+	FuzzyTrigger bool
+}
+
+type UpdateTranslationsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Translations struct {
+			Parsed  int64 `json:"parsed"`
+			Updated int64 `json:"updated"`
+		} `json:"translations"`
+	} `json:"result"`
+}
+
+// UpdateTranslations Updates existing translations of a language.
+//
+// https://poeditor.com/docs/api#translations_update
+func (c *ClientImpl) UpdateTranslations(ctx context.Context, r UpdateTranslationsRequest) (*UpdateTranslationsResponse, error) {
+	data, err := json.Marshal(r.Translations)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal data")
+	}
+
+	resp, err := c.post(ctx, "/v2/translations/update", map[string]string{
+		"id":            fmt.Sprintf("%d", r.ID),
+		"language":      r.Language,
+		"fuzzy_trigger": formDataBool(r.FuzzyTrigger),
+		"data":          string(data),
+	}, &UpdateTranslationsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*UpdateTranslationsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+type DeleteTranslationsRequest struct {
+	ID       int
+	Language string
+	Terms    []TermKey
+}
+
+type DeleteTranslationsResponse struct {
+	Response struct {
+		Status  string `json:"status"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"response"`
+	Result struct {
+		Translations struct {
+			Parsed  int64 `json:"parsed"`
+			Deleted int64 `json:"deleted"`
+		} `json:"translations"`
+	} `json:"result"`
+}
+
+// DeleteTranslations Deletes the translations of terms in a language. The terms are kept.
+//
+// https://poeditor.com/docs/api#translations_delete
+func (c *ClientImpl) DeleteTranslations(ctx context.Context, r DeleteTranslationsRequest) (*DeleteTranslationsResponse, error) {
+	data, err := json.Marshal(r.Terms)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to marshal data")
+	}
+
+	resp, err := c.post(ctx, "/v2/translations/delete", map[string]string{
+		"id":       fmt.Sprintf("%d", r.ID),
+		"language": r.Language,
+		"data":     string(data),
+	}, &DeleteTranslationsResponse{})
+	if err != nil {
+		return nil, err
+	}
+
+	res, ok := resp.Result().(*DeleteTranslationsResponse)
+	if !ok {
+		return nil, ErrFailedToUnmarshalResponse
+	}
+
+	if err := responseError(res.Response.Code, res.Response.Message, r.ID, r.Language); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}